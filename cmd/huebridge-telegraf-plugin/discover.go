@@ -0,0 +1,41 @@
+// discover.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hdecarne-github/huebridge-telegraf-plugin/plugins/inputs/huebridge"
+)
+
+// runDiscover implements the discover command, which lists the bridges found on the local network.
+func runDiscover(args []string) int {
+	discoverFlags := flag.NewFlagSet("discover", flag.ExitOnError)
+	timeout := discoverFlags.Duration("timeout", 3*time.Second, "how long to wait for bridges to answer")
+	discoverFlags.Parse(args)
+	bridges, err := huebridge.Discover(*timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Err: %s\n", err)
+		return 1
+	}
+	if len(bridges) == 0 {
+		fmt.Fprintln(os.Stderr, "No bridges found")
+		return 1
+	}
+	out := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(out, "BRIDGE ID\tADDRESS\tMODEL\tCONFIG URL")
+	for _, bridge := range bridges {
+		fmt.Fprintf(out, "%s\t%s\t%s\tauto:%s\n", bridge.BridgeId, bridge.Address, bridge.Model, bridge.BridgeId)
+	}
+	out.Flush()
+	return 0
+}
@@ -32,6 +32,16 @@ var err error
 // // now the shim.Run() call as below. Note the shim is only intended to run a single plugin.
 //
 func main() {
+	// dispatch the maintenance commands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "pair":
+			os.Exit(runPair(os.Args[2:]))
+		case "discover":
+			os.Exit(runDiscover(os.Args[2:]))
+		}
+	}
+
 	// parse command line options
 	flag.Parse()
 	if *pollIntervalDisabled {
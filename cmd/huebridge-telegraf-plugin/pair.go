@@ -0,0 +1,84 @@
+// pair.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hdecarne-github/huebridge-telegraf-plugin/plugins/inputs/huebridge"
+)
+
+// runPair implements the pair command, which creates a new application key for a bridge and
+// prints (or writes) the corresponding plugin configuration.
+func runPair(args []string) int {
+	pairFlags := flag.NewFlagSet("pair", flag.ExitOnError)
+	bridge := pairFlags.String("bridge", "", "IP, DNS name or base url of the bridge to pair with")
+	deviceType := pairFlags.String("devicetype", "huebridge-telegraf-plugin", "the device type to register the application key for")
+	timeout := pairFlags.Duration("timeout", 60*time.Second, "how long to wait for the link button to be pressed")
+	output := pairFlags.String("output", "", "path of the config file to write (default is to print the config)")
+	force := pairFlags.Bool("force", false, "overwrite an already existing output file")
+	pairFlags.Parse(args)
+	if *bridge == "" {
+		fmt.Fprintln(os.Stderr, "Err: missing -bridge option")
+		pairFlags.Usage()
+		return 2
+	}
+	// fail early, before the link button has been pressed
+	if *output != "" && !*force {
+		_, err := os.Stat(*output)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Err: output file %s already exists (use -force to overwrite it)\n", *output)
+			return 1
+		}
+	}
+	bridgeUrl := *bridge
+	if !strings.Contains(bridgeUrl, "://") {
+		bridgeUrl = "https://" + bridgeUrl
+	}
+	fmt.Fprintf(os.Stderr, "Press the link button of bridge %s to continue (waiting %s)...\n", bridgeUrl, *timeout)
+	applicationKey, err := huebridge.Pair(bridgeUrl, *deviceType, *timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Err: %s\n", err)
+		return 1
+	}
+	config := huebridge.PairedConfig(bridgeUrl, applicationKey)
+	if *output == "" {
+		fmt.Print(config)
+		return 0
+	}
+	// the config contains the application key and therefore is not world-readable
+	err = writeConfig(*output, config, *force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Err: %s\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Config written to: %s\n", *output)
+	return 0
+}
+
+// writeConfig writes the config file, refusing to overwrite an existing file unless forced to.
+func writeConfig(output string, config string, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(output, flags, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(config)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
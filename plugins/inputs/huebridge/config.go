@@ -0,0 +1,288 @@
+// config.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package huebridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf/config"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+	"golang.org/x/exp/slices"
+)
+
+// BridgeConfig defines a single bridge to query ([[inputs.huebridge.bridge]] sub-table). Options
+// not set within the sub-table default to the corresponding plugin-wide options.
+type BridgeConfig struct {
+	Url                string        `toml:"url"`
+	ApplicationKey     config.Secret `toml:"application_key"`
+	ApplicationKeyEnv  string        `toml:"application_key_env"`
+	ApplicationKeyFile string        `toml:"application_key_file"`
+	Name               string        `toml:"name"`
+	Timeout            int           `toml:"timeout"`
+	Resources          []string      `toml:"resources"`
+	RoomAssignments    [][]string    `toml:"room_assignments"`
+	TLSVerify          string        `toml:"tls_verify"`
+	TLSFingerprints    []string      `toml:"tls_fingerprints"`
+	common_tls.ClientConfig
+
+	enabledResources   map[string]bool
+	cachedClientLock   sync.Mutex
+	cachedClient       *http.Client
+	cachedStreamClient *http.Client
+	requestSlots       chan struct{}
+	requestSlotsOnce   sync.Once
+}
+
+func (plugin *HueBridge) Init() error {
+	err := plugin.checkConfig()
+	if err != nil {
+		return err
+	}
+	bridges := make([]*BridgeConfig, 0, len(plugin.Bridges)+len(plugin.BridgeConfigs))
+	// the legacy tuples are translated into bridge configs with the plugin-wide options applied
+	for i, bridge := range plugin.Bridges {
+		if len(bridge) != 2 {
+			// the entry is not printed, as it may contain the application key
+			return fmt.Errorf("huebridge: Invalid bridge entry #%d (expected: [<base url>, <application key>])", i+1)
+		}
+		bridges = append(bridges, plugin.newBridgeConfig(&BridgeConfig{Url: bridge[0], ApplicationKey: config.NewSecret([]byte(bridge[1]))}))
+	}
+	for _, bridge := range plugin.BridgeConfigs {
+		bridges = append(bridges, plugin.newBridgeConfig(bridge))
+	}
+	if len(bridges) == 0 {
+		return errors.New("huebridge: Empty bridge list")
+	}
+	urls := make(map[string]bool)
+	for _, bridge := range bridges {
+		err = checkBridgeConfig(bridge)
+		if err != nil {
+			return err
+		}
+		if urls[bridge.Url] {
+			return fmt.Errorf("huebridge: Duplicate bridge url: %s", bridge.Url)
+		}
+		urls[bridge.Url] = true
+	}
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	plugin.bridges = bridges
+	plugin.activeBridges = make(map[*BridgeConfig]string)
+	plugin.activeBridgeUrls = make(map[string]*BridgeConfig)
+	plugin.defaultBridge = nil
+	return nil
+}
+
+// newBridgeConfig creates the effective config of a bridge by merging the given bridge options
+// with the plugin-wide ones.
+func (plugin *HueBridge) newBridgeConfig(bridge *BridgeConfig) *BridgeConfig {
+	effective := &BridgeConfig{
+		Url:                bridge.Url,
+		ApplicationKey:     bridge.ApplicationKey,
+		ApplicationKeyEnv:  bridge.ApplicationKeyEnv,
+		ApplicationKeyFile: bridge.ApplicationKeyFile,
+		Name:               bridge.Name,
+		Timeout:            bridge.Timeout,
+		Resources:          bridge.Resources,
+		RoomAssignments:    append(append([][]string{}, bridge.RoomAssignments...), plugin.RoomAssignments...),
+		TLSVerify:          bridge.TLSVerify,
+		TLSFingerprints:    bridge.TLSFingerprints,
+		ClientConfig:       bridge.ClientConfig,
+	}
+	if effective.Timeout == 0 {
+		effective.Timeout = plugin.Timeout
+	}
+	if effective.TLSVerify == "" {
+		effective.TLSVerify = plugin.TLSVerify
+	}
+	if len(effective.TLSFingerprints) == 0 {
+		effective.TLSFingerprints = plugin.TLSFingerprints
+	}
+	if effective.TLSCA == "" {
+		effective.TLSCA = plugin.TLSCA
+	}
+	if effective.TLSCert == "" && effective.TLSKey == "" {
+		effective.TLSCert = plugin.TLSCert
+		effective.TLSKey = plugin.TLSKey
+		effective.TLSKeyPwd = plugin.TLSKeyPwd
+	}
+	if effective.TLSMinVersion == "" {
+		effective.TLSMinVersion = plugin.TLSMinVersion
+	}
+	if effective.ServerName == "" {
+		effective.ServerName = plugin.ServerName
+	}
+	effective.InsecureSkipVerify = effective.InsecureSkipVerify || plugin.InsecureSkipVerify
+	if len(effective.Resources) > 0 {
+		effective.enabledResources = make(map[string]bool)
+		for _, resource := range effective.Resources {
+			effective.enabledResources[resource] = true
+		}
+	}
+	return effective
+}
+
+func checkBridgeConfig(bridge *BridgeConfig) error {
+	if bridge.Url == "" {
+		return errors.New("huebridge: Missing bridge url option")
+	}
+	applicationKeySources := 0
+	for _, applicationKeySource := range []bool{!bridge.ApplicationKey.Empty(), bridge.ApplicationKeyEnv != "", bridge.ApplicationKeyFile != ""} {
+		if applicationKeySource {
+			applicationKeySources++
+		}
+	}
+	if applicationKeySources == 0 {
+		return fmt.Errorf("huebridge: Missing application_key option for bridge: %s", bridge.Url)
+	}
+	if applicationKeySources > 1 {
+		return fmt.Errorf("huebridge: Only one of application_key, application_key_env or application_key_file may be set for bridge: %s", bridge.Url)
+	}
+	if bridge.Timeout < 0 {
+		return fmt.Errorf("huebridge: Invalid timeout option for bridge %s: %d", bridge.Url, bridge.Timeout)
+	}
+	for _, resource := range bridge.Resources {
+		if !slices.Contains(eventResourceTypes, resource) {
+			return fmt.Errorf("huebridge: Invalid resources option for bridge %s: %s", bridge.Url, resource)
+		}
+	}
+	switch bridge.TLSVerify {
+	case "", tlsVerifyNone:
+		// the verification options would otherwise be silently ignored
+		if bridge.TLSCA != "" {
+			return fmt.Errorf("huebridge: tls_ca option requires tls_verify = \"ca\" for bridge: %s", bridge.Url)
+		}
+		if len(bridge.TLSFingerprints) > 0 {
+			return fmt.Errorf("huebridge: tls_fingerprints option requires tls_verify = \"fingerprint\" for bridge: %s", bridge.Url)
+		}
+	case tlsVerifyHue:
+	case tlsVerifyCA:
+		if bridge.InsecureSkipVerify {
+			return fmt.Errorf("huebridge: insecure_skip_verify option conflicts with tls_verify = \"ca\" for bridge: %s", bridge.Url)
+		}
+	case tlsVerifyFingerprint:
+		if len(bridge.TLSFingerprints) == 0 {
+			return fmt.Errorf("huebridge: Empty tls_fingerprints option for bridge: %s", bridge.Url)
+		}
+		for _, fingerprint := range bridge.TLSFingerprints {
+			decoded, err := hex.DecodeString(normalizeFingerprint(fingerprint))
+			if err != nil || len(decoded) != sha256.Size {
+				return fmt.Errorf("huebridge: Invalid tls_fingerprints option for bridge %s: %s", bridge.Url, fingerprint)
+			}
+		}
+	default:
+		return fmt.Errorf("huebridge: Invalid tls_verify option for bridge %s: %s", bridge.Url, bridge.TLSVerify)
+	}
+	return nil
+}
+
+// activateBridge resolves the configured bridge url and records the bridge config for the resolved
+// url, which is the one passed to (and used to look up the bridge options by) the processing functions.
+// If the resolved url has changed (e.g. a discovered bridge got a new address), the state recorded for
+// the previous url is dropped. A resolved url already used by another bridge config (e.g. "auto" and
+// the bridge's explicit url) is rejected, as the bridge options are looked up by the resolved url.
+func (plugin *HueBridge) activateBridge(bridge *BridgeConfig) (string, error) {
+	resolvedBridgeUrl, err := plugin.resolveBridgeUrl(bridge.Url)
+	if err != nil {
+		return "", err
+	}
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	previousBridgeUrl, active := plugin.activeBridges[bridge]
+	if active && previousBridgeUrl != resolvedBridgeUrl {
+		if plugin.Debug {
+			plugin.Log.Infof("Bridge %s moved from %s to %s", bridge.Url, previousBridgeUrl, resolvedBridgeUrl)
+		}
+		delete(plugin.activeBridges, bridge)
+		delete(plugin.activeBridgeUrls, previousBridgeUrl)
+		plugin.dropBridgeState(previousBridgeUrl)
+	}
+	activeBridge := plugin.activeBridgeUrls[resolvedBridgeUrl]
+	if activeBridge != nil && activeBridge != bridge {
+		return "", fmt.Errorf("bridge %s resolves to url %s, which is already used by bridge %s", bridge.Url, resolvedBridgeUrl, activeBridge.Url)
+	}
+	plugin.activeBridges[bridge] = resolvedBridgeUrl
+	plugin.activeBridgeUrls[resolvedBridgeUrl] = bridge
+	return resolvedBridgeUrl, nil
+}
+
+// dropBridgeState drops all state recorded for the given bridge url (the state lock must be held).
+func (plugin *HueBridge) dropBridgeState(bridgeUrl string) {
+	delete(plugin.bridgeIds, bridgeUrl)
+	delete(plugin.bridgeConfigs, bridgeUrl)
+	delete(plugin.requestStats, bridgeUrl)
+	delete(plugin.snapshots, bridgeUrl)
+	delete(plugin.streamingLights, bridgeUrl)
+	delete(plugin.deviceInfoReported, bridgeUrl)
+	for seriesKey, snapshot := range plugin.emittedSnapshots {
+		if snapshot.tags["huebridge_url"] == bridgeUrl {
+			delete(plugin.emittedSnapshots, seriesKey)
+		}
+	}
+}
+
+// bridgeConfig gets the config of the given (resolved) bridge url. Bridges not part of the plugin
+// config (e.g. during pairing) get a config consisting of the plugin-wide options.
+func (plugin *HueBridge) bridgeConfig(bridgeUrl string) *BridgeConfig {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	bridge := plugin.activeBridgeUrls[bridgeUrl]
+	if bridge == nil {
+		if plugin.defaultBridge == nil {
+			plugin.defaultBridge = plugin.newBridgeConfig(&BridgeConfig{})
+		}
+		bridge = plugin.defaultBridge
+	}
+	return bridge
+}
+
+func (plugin *HueBridge) isResourceEnabled(bridgeUrl string, resourceType string) bool {
+	enabledResources := plugin.bridgeConfig(bridgeUrl).enabledResources
+	return enabledResources == nil || enabledResources[resourceType]
+}
+
+func (plugin *HueBridge) roomAssignments(bridgeUrl string) [][]string {
+	return plugin.bridgeConfig(bridgeUrl).RoomAssignments
+}
+
+// applicationKey gets the bridge's application key from the configured source. The key is retrieved
+// every time it's needed, hence a changed key is picked up without restart. Neither the key nor any
+// part of it must end up in log output or error messages.
+func (bridge *BridgeConfig) applicationKey() (string, error) {
+	if bridge.ApplicationKeyEnv != "" {
+		applicationKey := os.Getenv(bridge.ApplicationKeyEnv)
+		if applicationKey == "" {
+			return "", fmt.Errorf("application key environment variable %s of bridge %s not set", bridge.ApplicationKeyEnv, bridge.Url)
+		}
+		return applicationKey, nil
+	}
+	if bridge.ApplicationKeyFile != "" {
+		applicationKeyData, err := os.ReadFile(bridge.ApplicationKeyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read application key of bridge %s (cause: %w)", bridge.Url, err)
+		}
+		applicationKey := strings.TrimSpace(string(applicationKeyData))
+		if applicationKey == "" {
+			return "", fmt.Errorf("application key file %s of bridge %s is empty", bridge.ApplicationKeyFile, bridge.Url)
+		}
+		return applicationKey, nil
+	}
+	applicationKeySecret, err := bridge.ApplicationKey.Get()
+	if err != nil {
+		return "", fmt.Errorf("failed to get application key of bridge %s (cause: %w)", bridge.Url, err)
+	}
+	defer applicationKeySecret.Destroy()
+	return applicationKeySecret.String(), nil
+}
@@ -0,0 +1,319 @@
+// discover.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package huebridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const autoBridge = "auto"
+const autoBridgePrefix = autoBridge + ":"
+const discoveryTimeout = 3 * time.Second
+
+const mdnsAddress = "224.0.0.251:5353"
+const mdnsService = "_hue._tcp.local."
+const mdnsTypePTR = 12
+const mdnsTypeTXT = 16
+const mdnsClassIN = 1
+const mdnsClassUnicastResponse = 0x8000
+
+const ssdpAddress = "239.255.255.250:1900"
+const ssdpSearch = "M-SEARCH * HTTP/1.1\r\nHOST: " + ssdpAddress + "\r\nMAN: \"ssdp:discover\"\r\nMX: 2\r\nST: ssdp:all\r\n\r\n"
+
+// DiscoveredBridge describes a bridge found on the local network.
+type DiscoveredBridge struct {
+	BridgeId string
+	Address  string
+	Model    string
+	Url      string
+}
+
+// discoverBridges is the discovery function used by the plugin (replaceable for testing)
+var discoverBridges = Discover
+
+// Discover browses the local network for bridges. The bridges are discovered via mDNS and in case
+// no bridge answers via SSDP/UPnP.
+func Discover(timeout time.Duration) ([]DiscoveredBridge, error) {
+	bridges, mdnsErr := discoverMDNS(timeout)
+	if len(bridges) > 0 {
+		return bridges, nil
+	}
+	bridges, ssdpErr := discoverSSDP(timeout)
+	if len(bridges) > 0 {
+		return bridges, nil
+	}
+	return nil, errors.Join(mdnsErr, ssdpErr)
+}
+
+// resolveBridgeUrl maps a bridge url of the form auto[:<bridge id>] to the url of the matching
+// discovered bridge. All other bridge urls are returned as is.
+func (plugin *HueBridge) resolveBridgeUrl(bridgeUrl string) (string, error) {
+	if bridgeUrl != autoBridge && !strings.HasPrefix(bridgeUrl, autoBridgePrefix) {
+		return bridgeUrl, nil
+	}
+	bridgeId := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(bridgeUrl, autoBridge), ":"))
+	// discoveries are serialized, as concurrently processed bridges share the discovery results
+	plugin.discoveryLock.Lock()
+	defer plugin.discoveryLock.Unlock()
+	if plugin.discoveredBridges == nil || (bridgeId != "" && plugin.discoveredBridges[bridgeId] == "") {
+		if plugin.Debug {
+			plugin.Log.Infof("Discovering bridges...")
+		}
+		bridges, err := discoverBridges(discoveryTimeout)
+		if err != nil {
+			return "", fmt.Errorf("failed to discover bridge %s (cause: %w)", bridgeUrl, err)
+		}
+		plugin.discoveredBridges = make(map[string]string)
+		for _, bridge := range bridges {
+			plugin.discoveredBridges[bridge.BridgeId] = bridge.Url
+		}
+	}
+	if bridgeId == "" {
+		if len(plugin.discoveredBridges) != 1 {
+			return "", fmt.Errorf("failed to discover bridge %s (%d bridges found, use auto:<bridge id> to select one)", bridgeUrl, len(plugin.discoveredBridges))
+		}
+		for _, discoveredUrl := range plugin.discoveredBridges {
+			return discoveredUrl, nil
+		}
+	}
+	discoveredUrl := plugin.discoveredBridges[bridgeId]
+	if discoveredUrl == "" {
+		return "", fmt.Errorf("failed to discover bridge %s (bridge not found)", bridgeUrl)
+	}
+	return discoveredUrl, nil
+}
+
+// forgetDiscoveredBridges drops the discovery results, causing a new discovery during the next
+// bridge url resolution (e.g. because a bridge's address has changed).
+func (plugin *HueBridge) forgetDiscoveredBridges() {
+	plugin.discoveryLock.Lock()
+	defer plugin.discoveryLock.Unlock()
+	plugin.discoveredBridges = nil
+}
+
+func discoverMDNS(timeout time.Duration) ([]DiscoveredBridge, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	address, err := net.ResolveUDPAddr("udp4", mdnsAddress)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.WriteTo(mdnsQuery(mdnsService), address)
+	if err != nil {
+		return nil, err
+	}
+	bridges := make([]DiscoveredBridge, 0)
+	err = receiveUntil(conn, time.Now().Add(timeout), func(response []byte, source *net.UDPAddr) {
+		txt, err := parseMDNSResponse(response)
+		if err != nil || txt["bridgeid"] == "" {
+			return
+		}
+		bridges = appendDiscoveredBridge(bridges, DiscoveredBridge{
+			BridgeId: strings.ToLower(txt["bridgeid"]),
+			Address:  source.IP.String(),
+			Model:    txt["modelid"],
+			Url:      "https://" + source.IP.String(),
+		})
+	})
+	return bridges, err
+}
+
+func discoverSSDP(timeout time.Duration) ([]DiscoveredBridge, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	address, err := net.ResolveUDPAddr("udp4", ssdpAddress)
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.WriteTo([]byte(ssdpSearch), address)
+	if err != nil {
+		return nil, err
+	}
+	locations := make(map[string]string)
+	err = receiveUntil(conn, time.Now().Add(timeout), func(response []byte, source *net.UDPAddr) {
+		bridgeId, location := parseSSDPResponse(response)
+		if bridgeId != "" && location != "" {
+			locations[strings.ToLower(bridgeId)] = location
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	bridges := make([]DiscoveredBridge, 0)
+	client := &http.Client{Timeout: timeout}
+	for bridgeId, location := range locations {
+		locationUrl, err := url.Parse(location)
+		if err != nil {
+			continue
+		}
+		bridge := DiscoveredBridge{
+			BridgeId: bridgeId,
+			Address:  locationUrl.Hostname(),
+			Url:      "https://" + locationUrl.Hostname(),
+		}
+		// the model is only available via the description; failing to fetch it is not fatal
+		description, err := fetchSSDPDescription(client, location)
+		if err == nil {
+			bridge.Model = description.Device.ModelNumber
+		}
+		bridges = appendDiscoveredBridge(bridges, bridge)
+	}
+	return bridges, nil
+}
+
+func receiveUntil(conn *net.UDPConn, deadline time.Time, receive func([]byte, *net.UDPAddr)) error {
+	err := conn.SetReadDeadline(deadline)
+	if err != nil {
+		return err
+	}
+	buffer := make([]byte, 9000)
+	for {
+		n, source, err := conn.ReadFromUDP(buffer)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return nil
+			}
+			return err
+		}
+		receive(buffer[:n], source)
+	}
+}
+
+func appendDiscoveredBridge(bridges []DiscoveredBridge, bridge DiscoveredBridge) []DiscoveredBridge {
+	for _, discoveredBridge := range bridges {
+		if discoveredBridge.BridgeId == bridge.BridgeId {
+			return bridges
+		}
+	}
+	return append(bridges, bridge)
+}
+
+func mdnsQuery(service string) []byte {
+	var query bytes.Buffer
+	// header: id, flags, 1 question, no answer, authority or additional records
+	_ = binary.Write(&query, binary.BigEndian, [6]uint16{0, 0, 1, 0, 0, 0})
+	for _, label := range strings.Split(strings.TrimSuffix(service, "."), ".") {
+		query.WriteByte(byte(len(label)))
+		query.WriteString(label)
+	}
+	query.WriteByte(0)
+	_ = binary.Write(&query, binary.BigEndian, [2]uint16{mdnsTypePTR, mdnsClassIN | mdnsClassUnicastResponse})
+	return query.Bytes()
+}
+
+// parseMDNSResponse collects the key/value pairs of all TXT records contained in a mDNS response
+func parseMDNSResponse(response []byte) (map[string]string, error) {
+	if len(response) < 12 {
+		return nil, errors.New("invalid mDNS response")
+	}
+	questions := int(binary.BigEndian.Uint16(response[4:6]))
+	records := int(binary.BigEndian.Uint16(response[6:8])) + int(binary.BigEndian.Uint16(response[8:10])) + int(binary.BigEndian.Uint16(response[10:12]))
+	offset := 12
+	for i := 0; i < questions; i++ {
+		nameEnd, err := skipMDNSName(response, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = nameEnd + 4
+	}
+	txt := make(map[string]string)
+	for i := 0; i < records; i++ {
+		nameEnd, err := skipMDNSName(response, offset)
+		if err != nil {
+			return nil, err
+		}
+		if nameEnd+10 > len(response) {
+			return nil, errors.New("invalid mDNS record")
+		}
+		recordType := binary.BigEndian.Uint16(response[nameEnd : nameEnd+2])
+		dataLength := int(binary.BigEndian.Uint16(response[nameEnd+8 : nameEnd+10]))
+		dataStart := nameEnd + 10
+		if dataStart+dataLength > len(response) {
+			return nil, errors.New("invalid mDNS record data")
+		}
+		if recordType == mdnsTypeTXT {
+			data := response[dataStart : dataStart+dataLength]
+			for len(data) > 0 && int(data[0]) < len(data) {
+				key, value, _ := strings.Cut(string(data[1:1+int(data[0])]), "=")
+				txt[strings.ToLower(key)] = value
+				data = data[1+int(data[0]):]
+			}
+		}
+		offset = dataStart + dataLength
+	}
+	return txt, nil
+}
+
+func skipMDNSName(response []byte, offset int) (int, error) {
+	for offset < len(response) {
+		length := int(response[offset])
+		switch {
+		case length == 0:
+			return offset + 1, nil
+		case length&0xc0 == 0xc0:
+			// compression pointer terminates the name
+			return offset + 2, nil
+		default:
+			offset += 1 + length
+		}
+	}
+	return 0, errors.New("invalid mDNS name")
+}
+
+func parseSSDPResponse(response []byte) (string, string) {
+	httpResponse, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(response)), nil)
+	if err != nil {
+		return "", ""
+	}
+	httpResponse.Body.Close()
+	return httpResponse.Header.Get("hue-bridgeid"), httpResponse.Header.Get("Location")
+}
+
+type ssdpDescription struct {
+	Device ssdpDescriptionDevice `xml:"device"`
+}
+
+type ssdpDescriptionDevice struct {
+	ModelName    string `xml:"modelName"`
+	ModelNumber  string `xml:"modelNumber"`
+	SerialNumber string `xml:"serialNumber"`
+}
+
+func fetchSSDPDescription(client *http.Client, location string) (*ssdpDescription, error) {
+	response, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve description from %s (%s)", location, response.Status)
+	}
+	var description ssdpDescription
+	err = xml.NewDecoder(response.Body).Decode(&description)
+	if err != nil {
+		return nil, err
+	}
+	return &description, nil
+}
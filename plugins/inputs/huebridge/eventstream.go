@@ -0,0 +1,536 @@
+// eventstream.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package huebridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"golang.org/x/exp/slices"
+)
+
+const eventStreamPath = "/eventstream/clip/v2"
+const eventStreamMaxEventSize = 1024 * 1024
+
+var eventStreamReconnectDelay = 5 * time.Second
+
+// The resource types which are evaluated when received via the event stream
+var eventResourceTypes = []string{"light", "temperature", "light_level", "motion", "camera_motion", "convenience_area_motion", "security_area_motion", "grouped_motion", "grouped_light_level", "device_power", "button", "relative_rotary", "contact", "tamper", "zigbee_connectivity", "zgp_connectivity", "grouped_light", "device_software_update", "scene", "smart_scene", "behavior_instance", "entertainment_configuration", "geolocation"}
+
+func (plugin *HueBridge) Start(a telegraf.Accumulator) error {
+	if !plugin.EventStream {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	plugin.cancelEventStreams = cancel
+	for _, bridge := range plugin.bridges {
+		plugin.eventStreams.Add(1)
+		go plugin.runEventStream(ctx, a, bridge)
+	}
+	return nil
+}
+
+func (plugin *HueBridge) Stop() {
+	if plugin.cancelEventStreams != nil {
+		plugin.cancelEventStreams()
+		plugin.eventStreams.Wait()
+		plugin.cancelEventStreams = nil
+	}
+}
+
+// gatherEventStreamBridges reports the stats of the event stream bridges, which otherwise would only
+// be reported after every (re-)connect.
+func (plugin *HueBridge) gatherEventStreamBridges(a telegraf.Accumulator) {
+	plugin.stateLock.Lock()
+	bridgeUrls := make([]string, 0, len(plugin.activeBridges))
+	for _, bridgeUrl := range plugin.activeBridges {
+		bridgeUrls = append(bridgeUrls, bridgeUrl)
+	}
+	plugin.stateLock.Unlock()
+	bridgeTasks := make([]func(), 0, len(bridgeUrls))
+	for _, bridgeUrl := range bridgeUrls {
+		bridgeUrl := bridgeUrl
+		bridgeTasks = append(bridgeTasks, func() {
+			plugin.evalBridge(plugin.newBridgeAccumulator(a, bridgeUrl, nil), bridgeUrl, "", nil, nil)
+		})
+	}
+	plugin.runConcurrently(bridgeTasks...)
+}
+
+func (plugin *HueBridge) runEventStream(ctx context.Context, a telegraf.Accumulator, bridge *BridgeConfig) {
+	defer plugin.eventStreams.Done()
+	for {
+		err := plugin.processConfiguredEventStream(ctx, a, bridge)
+		if ctx.Err() != nil {
+			return
+		}
+		a.AddError(fmt.Errorf("event stream of bridge %s interrupted (cause: %w)", bridge.Url, err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventStreamReconnectDelay):
+		}
+	}
+}
+
+func (plugin *HueBridge) processConfiguredEventStream(ctx context.Context, a telegraf.Accumulator, bridge *BridgeConfig) error {
+	applicationKey, err := bridge.applicationKey()
+	if err != nil {
+		return err
+	}
+	resolvedBridgeUrl, err := plugin.activateBridge(bridge)
+	if err != nil {
+		return err
+	}
+	err = plugin.processEventStream(ctx, a, resolvedBridgeUrl, applicationKey)
+	if resolvedBridgeUrl != bridge.Url {
+		// the bridge's address may have changed, re-discover before reconnecting
+		plugin.forgetDiscoveredBridges()
+	}
+	return err
+}
+
+type eventStreamState struct {
+	devices   *devicesList
+	rooms     *roomsList
+	zones     *roomsList
+	resources map[string]map[string]interface{}
+	// the light states needed to evaluate the grouped lights (fetched on first use)
+	lights map[string]lightData
+	// the motion areas needed to evaluate the MotionAware motions (fetched on first use)
+	motionAreas *motionAreaConfigurationsList
+}
+
+func (plugin *HueBridge) processEventStream(ctx context.Context, a telegraf.Accumulator, bridgeUrl string, applicationKey string) error {
+	streamUrl, err := resolveUrl(bridgeUrl, eventStreamPath)
+	if err != nil {
+		return err
+	}
+	if plugin.Debug {
+		plugin.Log.Infof("Connecting event stream: %s", streamUrl)
+	}
+	request, err := http.NewRequestWithContext(ctx, "GET", streamUrl.String(), nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Add("hue-application-key", applicationKey)
+	request.Header.Add("Accept", "text/event-stream")
+	client, err := plugin.getStreamClient(plugin.bridgeConfig(bridgeUrl))
+	if err != nil {
+		return err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to connect event stream %s (%s)", streamUrl, response.Status)
+	}
+	bridge, err := plugin.fetchBridge(a, bridgeUrl, applicationKey)
+	if err != nil {
+		a.AddError(fmt.Errorf("failed to eval bridge (cause: %w)", err))
+	}
+	a = plugin.newBridgeAccumulator(a, bridgeUrl, bridge)
+	// backfill the current state, as events may have been missed while being disconnected
+	state := &eventStreamState{
+		resources: make(map[string]map[string]interface{}),
+	}
+	err = plugin.refreshEventStreamState(a, bridgeUrl, applicationKey, state)
+	if err != nil {
+		return err
+	}
+	backfillStart := time.Now()
+	plugin.processBridgeResources(a, bridgeUrl, applicationKey, state.devices, state.rooms, state.zones)
+	plugin.evalBridge(a, bridgeUrl, applicationKey, bridge, state.devices)
+	// the backfill covers all series of the bridge, series not seen are gone
+	plugin.pruneEmittedSnapshots(bridgeUrl, backfillStart)
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), eventStreamMaxEventSize)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data.Len() > 0 {
+				a.AddError(plugin.processEvents(a, bridgeUrl, applicationKey, state, data.String()))
+				data.Reset()
+			}
+		} else if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	err = scanner.Err()
+	if err != nil {
+		return err
+	}
+	return errors.New("event stream closed by bridge")
+}
+
+func (plugin *HueBridge) refreshEventStreamState(a telegraf.Accumulator, bridgeUrl string, applicationKey string, state *eventStreamState) error {
+	devices, err := plugin.fetchDevices(a, bridgeUrl, applicationKey)
+	if err != nil {
+		return err
+	}
+	rooms, err := plugin.fetchRooms(a, bridgeUrl, applicationKey)
+	if err != nil {
+		return err
+	}
+	zones, err := plugin.fetchZones(a, bridgeUrl, applicationKey)
+	if err != nil {
+		return err
+	}
+	state.devices = devices
+	state.rooms = rooms
+	state.zones = zones
+	return nil
+}
+
+type eventData struct {
+	Type string            `json:"type"`
+	Data []json.RawMessage `json:"data"`
+}
+
+type eventResource struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+}
+
+func (plugin *HueBridge) processEvents(a telegraf.Accumulator, bridgeUrl string, applicationKey string, state *eventStreamState, data string) error {
+	if plugin.Debug {
+		plugin.Log.Infof("Processing events: %s", data)
+	}
+	var events []eventData
+	err := json.Unmarshal([]byte(data), &events)
+	if err != nil {
+		return fmt.Errorf("failed to decode events (cause: %w)", err)
+	}
+	refreshState := false
+	for _, event := range events {
+		for _, resourceData := range event.Data {
+			var resource eventResource
+			err = json.Unmarshal(resourceData, &resource)
+			if err != nil {
+				return fmt.Errorf("failed to decode event resource (cause: %w)", err)
+			}
+			if resource.Type == "device" || resource.Type == "room" || resource.Type == "zone" {
+				refreshState = true
+			}
+			if resource.Type == "motion_area_configuration" {
+				// re-fetched on next use
+				state.motionAreas = nil
+			}
+			if resource.Type == "light" {
+				err = state.updateLight(event.Type, &resource, resourceData)
+				if err != nil {
+					a.AddError(fmt.Errorf("failed to track light %s event (cause: %w)", event.Type, err))
+				}
+			}
+			if event.Type == "update" && slices.Contains(eventResourceTypes, resource.Type) && plugin.isResourceEnabled(bridgeUrl, resource.Type) {
+				err = plugin.processUpdateEvent(a, bridgeUrl, applicationKey, state, &resource, resourceData)
+				if err != nil {
+					a.AddError(fmt.Errorf("failed to eval %s update event (cause: %w)", resource.Type, err))
+				}
+			} else if event.Type == "delete" {
+				delete(state.resources, resource.Id)
+			}
+		}
+	}
+	if refreshState {
+		return plugin.refreshEventStreamState(a, bridgeUrl, applicationKey, state)
+	}
+	return nil
+}
+
+func (plugin *HueBridge) processUpdateEvent(a telegraf.Accumulator, bridgeUrl string, applicationKey string, state *eventStreamState, resource *eventResource, resourceData json.RawMessage) error {
+	// update events only carry the changed attributes, hence they are merged into the last
+	// known resource state before being evaluated
+	resourceState := state.resources[resource.Id]
+	if resourceState == nil {
+		fetched, err := plugin.fetchResource(bridgeUrl, applicationKey, resource.Type, resource.Id)
+		if err != nil {
+			return err
+		}
+		resourceState = fetched
+		state.resources[resource.Id] = resourceState
+	}
+	var update map[string]interface{}
+	err := json.Unmarshal(resourceData, &update)
+	if err != nil {
+		return err
+	}
+	mergeResource(resourceState, update)
+	mergedData, err := json.Marshal(resourceState)
+	if err != nil {
+		return err
+	}
+	return plugin.evalResource(a, bridgeUrl, applicationKey, resource.Type, mergedData, state)
+}
+
+func mergeResource(resourceState map[string]interface{}, update map[string]interface{}) {
+	for key, value := range update {
+		valueMap, valueIsMap := value.(map[string]interface{})
+		stateMap, stateIsMap := resourceState[key].(map[string]interface{})
+		if valueIsMap && stateIsMap {
+			mergeResource(stateMap, valueMap)
+		} else {
+			resourceState[key] = value
+		}
+	}
+}
+
+func (plugin *HueBridge) evalResource(a telegraf.Accumulator, bridgeUrl string, applicationKey string, resourceType string, resourceData []byte, state *eventStreamState) error {
+	devices := state.devices
+	rooms := state.rooms
+	zones := state.zones
+	switch resourceType {
+	case "light":
+		var light lightData
+		err := json.Unmarshal(resourceData, &light)
+		if err != nil {
+			return err
+		}
+		plugin.evalLights(a, bridgeUrl, &lightsStatus{Data: []lightData{light}}, devices, rooms, zones)
+	case "temperature":
+		var temperature temperatureData
+		err := json.Unmarshal(resourceData, &temperature)
+		if err != nil {
+			return err
+		}
+		plugin.evalTemperatures(a, bridgeUrl, &temperaturesStatus{Data: []temperatureData{temperature}}, devices, rooms, zones)
+	case "light_level":
+		var lightLevel lightLevelData
+		err := json.Unmarshal(resourceData, &lightLevel)
+		if err != nil {
+			return err
+		}
+		plugin.evalLightLevels(a, bridgeUrl, &lightLevelsStatus{Data: []lightLevelData{lightLevel}}, devices, rooms, zones)
+	case "motion", "camera_motion", "convenience_area_motion", "security_area_motion":
+		var motion motionData
+		err := json.Unmarshal(resourceData, &motion)
+		if err != nil {
+			return err
+		}
+		motions := &motionsStatus{Data: []motionData{motion}}
+		if state.motionAreas == nil && motions.hasMotionAreaOwner() {
+			state.motionAreas, err = plugin.fetchMotionAreaConfigurations(a, bridgeUrl, applicationKey)
+			if err != nil {
+				return err
+			}
+		}
+		plugin.evalMotions(a, bridgeUrl, resourceType, motions, state.motionAreas, devices, rooms, zones)
+	case "grouped_motion":
+		var groupedMotion groupedMotionData
+		err := json.Unmarshal(resourceData, &groupedMotion)
+		if err != nil {
+			return err
+		}
+		plugin.evalGroupedMotions(a, bridgeUrl, &groupedMotionsStatus{Data: []groupedMotionData{groupedMotion}}, rooms, zones)
+	case "grouped_light_level":
+		var groupedLightLevel groupedLightLevelData
+		err := json.Unmarshal(resourceData, &groupedLightLevel)
+		if err != nil {
+			return err
+		}
+		plugin.evalGroupedLightLevels(a, bridgeUrl, &groupedLightLevelsStatus{Data: []groupedLightLevelData{groupedLightLevel}}, rooms, zones)
+	case "device_power":
+		var devicePower devicePowerData
+		err := json.Unmarshal(resourceData, &devicePower)
+		if err != nil {
+			return err
+		}
+		plugin.evalDevicePowers(a, bridgeUrl, &devicePowersStatus{Data: []devicePowerData{devicePower}}, devices)
+	case "button":
+		var button buttonData
+		err := json.Unmarshal(resourceData, &button)
+		if err != nil {
+			return err
+		}
+		plugin.evalButtons(a, bridgeUrl, &buttonsStatus{Data: []buttonData{button}}, devices, rooms, zones)
+	case "relative_rotary":
+		var relativeRotary relativeRotaryData
+		err := json.Unmarshal(resourceData, &relativeRotary)
+		if err != nil {
+			return err
+		}
+		plugin.evalRelativeRotaries(a, bridgeUrl, &relativeRotariesStatus{Data: []relativeRotaryData{relativeRotary}}, devices, rooms, zones)
+	case "contact":
+		var contact contactData
+		err := json.Unmarshal(resourceData, &contact)
+		if err != nil {
+			return err
+		}
+		plugin.evalContacts(a, bridgeUrl, &contactsStatus{Data: []contactData{contact}}, devices, rooms, zones)
+	case "tamper":
+		var tamper tamperData
+		err := json.Unmarshal(resourceData, &tamper)
+		if err != nil {
+			return err
+		}
+		plugin.evalTampers(a, bridgeUrl, &tampersStatus{Data: []tamperData{tamper}}, devices, rooms, zones)
+	case "zigbee_connectivity":
+		var zigbeeConnectivity zigbeeConnectivityData
+		err := json.Unmarshal(resourceData, &zigbeeConnectivity)
+		if err != nil {
+			return err
+		}
+		plugin.evalZigbeeConnectivities(a, bridgeUrl, &zigbeeConnectivitiesStatus{Data: []zigbeeConnectivityData{zigbeeConnectivity}}, devices, rooms, zones)
+	case "zgp_connectivity":
+		var zgpConnectivity zgpConnectivityData
+		err := json.Unmarshal(resourceData, &zgpConnectivity)
+		if err != nil {
+			return err
+		}
+		plugin.evalZgpConnectivities(a, bridgeUrl, &zgpConnectivitiesStatus{Data: []zgpConnectivityData{zgpConnectivity}}, devices, rooms, zones)
+	case "device_software_update":
+		var deviceSoftwareUpdate deviceSoftwareUpdateData
+		err := json.Unmarshal(resourceData, &deviceSoftwareUpdate)
+		if err != nil {
+			return err
+		}
+		plugin.evalDeviceSoftwareUpdates(a, bridgeUrl, &deviceSoftwareUpdatesStatus{Data: []deviceSoftwareUpdateData{deviceSoftwareUpdate}}, devices)
+	case "scene":
+		var scene sceneData
+		err := json.Unmarshal(resourceData, &scene)
+		if err != nil {
+			return err
+		}
+		plugin.evalScenes(a, bridgeUrl, &scenesStatus{Data: []sceneData{scene}}, rooms, zones)
+	case "smart_scene":
+		var smartScene smartSceneData
+		err := json.Unmarshal(resourceData, &smartScene)
+		if err != nil {
+			return err
+		}
+		plugin.evalSmartScenes(a, bridgeUrl, &smartScenesStatus{Data: []smartSceneData{smartScene}}, rooms, zones)
+	case "behavior_instance":
+		var behaviorInstance behaviorInstanceData
+		err := json.Unmarshal(resourceData, &behaviorInstance)
+		if err != nil {
+			return err
+		}
+		behaviorScripts, err := plugin.fetchBehaviorScripts(a, bridgeUrl, applicationKey)
+		if err != nil {
+			return err
+		}
+		plugin.evalBehaviorInstances(a, bridgeUrl, &behaviorInstancesStatus{Data: []behaviorInstanceData{behaviorInstance}}, behaviorScripts)
+	case "entertainment_configuration":
+		var entertainmentConfiguration entertainmentConfigurationData
+		err := json.Unmarshal(resourceData, &entertainmentConfiguration)
+		if err != nil {
+			return err
+		}
+		plugin.evalEntertainmentConfigurations(a, bridgeUrl, &entertainmentConfigurationsStatus{Data: []entertainmentConfigurationData{entertainmentConfiguration}}, state.devices)
+	case "geolocation":
+		var geolocation geolocationData
+		err := json.Unmarshal(resourceData, &geolocation)
+		if err != nil {
+			return err
+		}
+		geofenceClients, err := plugin.fetchGeofenceClients(a, bridgeUrl, applicationKey)
+		if err != nil {
+			return err
+		}
+		plugin.evalPresence(a, bridgeUrl, &geolocationsStatus{Data: []geolocationData{geolocation}}, geofenceClients)
+	case "grouped_light":
+		var groupedLight groupedLightData
+		err := json.Unmarshal(resourceData, &groupedLight)
+		if err != nil {
+			return err
+		}
+		// the light count requires the current state of all lights
+		lights, err := plugin.getEventStreamLights(a, bridgeUrl, applicationKey, state)
+		if err != nil {
+			return err
+		}
+		plugin.evalGroupedLights(a, bridgeUrl, &groupedLightsStatus{Data: []groupedLightData{groupedLight}}, lights, rooms, zones)
+	default:
+		return fmt.Errorf("unsupported resource type: %s", resourceType)
+	}
+	return nil
+}
+
+// getEventStreamLights gets the current light states. The lights are fetched once per connect and
+// kept up to date via the light events afterwards.
+func (plugin *HueBridge) getEventStreamLights(a telegraf.Accumulator, bridgeUrl string, applicationKey string, state *eventStreamState) (*lightsStatus, error) {
+	if state.lights == nil {
+		lights, err := plugin.fetchLights(a, bridgeUrl, applicationKey)
+		if err != nil {
+			return nil, err
+		}
+		state.lights = make(map[string]lightData)
+		for _, light := range lights.Data {
+			state.lights[light.Id] = light
+		}
+	}
+	lights := &lightsStatus{Data: make([]lightData, 0, len(state.lights))}
+	for _, light := range state.lights {
+		lights.Data = append(lights.Data, light)
+	}
+	return lights, nil
+}
+
+// updateLight applies a light event to the tracked light states
+func (state *eventStreamState) updateLight(eventType string, resource *eventResource, resourceData json.RawMessage) error {
+	if state.lights == nil {
+		// not yet fetched, nothing to track
+		return nil
+	}
+	switch eventType {
+	case "add", "update":
+		// update events only carry the changed attributes, which are decoded on top of the last known state
+		light := state.lights[resource.Id]
+		err := json.Unmarshal(resourceData, &light)
+		if err != nil {
+			return err
+		}
+		state.lights[resource.Id] = light
+	case "delete":
+		delete(state.lights, resource.Id)
+	}
+	return nil
+}
+
+type resourceList struct {
+	Data []map[string]interface{} `json:"data"`
+}
+
+func (plugin *HueBridge) fetchResource(bridgeUrl string, applicationKey string, resourceType string, resourceId string) (map[string]interface{}, error) {
+	var resourceList resourceList
+
+	jsonUrl, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/"+resourceType+"/"+resourceId, &resourceList)
+	if err != nil {
+		return nil, err
+	}
+	if len(resourceList.Data) != 1 {
+		return nil, fmt.Errorf("unexpected resource data received from %s", jsonUrl)
+	}
+	return resourceList.Data[0], nil
+}
+
+func (plugin *HueBridge) getStreamClient(bridge *BridgeConfig) (*http.Client, error) {
+	bridge.cachedClientLock.Lock()
+	defer bridge.cachedClientLock.Unlock()
+	if bridge.cachedStreamClient == nil {
+		transport, err := plugin.createTransport(bridge)
+		if err != nil {
+			return nil, err
+		}
+		// no overall client timeout here, as the event stream is kept open indefinitely
+		bridge.cachedStreamClient = &http.Client{
+			Transport: transport,
+		}
+	}
+	return bridge.cachedStreamClient, nil
+}
@@ -8,43 +8,93 @@
 package huebridge
 
 import (
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"golang.org/x/exp/slices"
 )
 
 type HueBridge struct {
-	Bridges         [][]string `toml:"bridges"`
-	Timeout         int        `toml:"timeout"`
-	RoomAssignments [][]string `toml:"room_assignments"`
-	Debug           bool       `toml:"debug"`
+	Bridges               [][]string      `toml:"bridges"`
+	BridgeConfigs         []*BridgeConfig `toml:"bridge"`
+	Timeout               int             `toml:"timeout"`
+	RoomAssignments       [][]string      `toml:"room_assignments"`
+	UnreachableLights     string          `toml:"unreachable_lights"`
+	StreamingLights       string          `toml:"streaming_lights"`
+	ZoneMembership        string          `toml:"zone_membership"`
+	DeviceTags            []string        `toml:"device_tags"`
+	DeviceInfoInterval    int             `toml:"device_info_interval"`
+	ReportTimestamps      bool            `toml:"report_timestamps"`
+	Emit                  string          `toml:"emit"`
+	HeartbeatInterval     int             `toml:"heartbeat_interval"`
+	MaxConcurrentRequests int             `toml:"max_concurrent_requests"`
+	Snapshot              bool            `toml:"snapshot"`
+	TLSVerify             string          `toml:"tls_verify"`
+	TLSFingerprints       []string        `toml:"tls_fingerprints"`
+	EventStream           bool            `toml:"eventstream"`
+	Debug                 bool            `toml:"debug"`
+	common_tls.ClientConfig
 
 	Log telegraf.Logger
 
-	cachedClient *http.Client
+	bridges            []*BridgeConfig
+	activeBridges      map[*BridgeConfig]string
+	activeBridgeUrls   map[string]*BridgeConfig
+	defaultBridge      *BridgeConfig
+	cancelEventStreams context.CancelFunc
+	eventStreams       sync.WaitGroup
+	stateLock          sync.Mutex
+	reportCounters     map[string]*reportCounter
+	reportedChanges    map[string]string
+	unreachableDevices map[string]bool
+	streamingLights    map[string]map[string]string
+	requestStats       map[string]*requestStats
+	bridgeIds          map[string]string
+	bridgeConfigs      map[string]*cachedBridgeConfig
+	deviceInfoReported map[string]time.Time
+	stateChanges       map[string]*stateChange
+	emittedSnapshots   map[string]*emittedSnapshot
+	snapshots          map[string]*resourceSnapshot
+	discoveryLock      sync.Mutex
+	discoveredBridges  map[string]string
 }
 
 func NewHueBridge() *HueBridge {
 	return &HueBridge{
-		Bridges: [][]string{},
-		Timeout: 10,
+		Bridges:               [][]string{},
+		Timeout:               10,
+		UnreachableLights:     lightsReport,
+		StreamingLights:       lightsReport,
+		ZoneMembership:        zoneMembershipNone,
+		DeviceTags:            []string{},
+		DeviceInfoInterval:    3600,
+		Emit:                  emitAll,
+		HeartbeatInterval:     600,
+		MaxConcurrentRequests: 4,
+		TLSVerify:             tlsVerifyNone,
 	}
 }
 
 func (plugin *HueBridge) SampleConfig() string {
 	return `
-  ## The Hue bridges to query (multiple tuples of base url, application key)
-  ## To create a application key issue the following command line for the targeted Hue bridge:
-  ## curl -X POST http://<bridge IP or DNS name>/api -H 'Content-Type: application/json' -d '{"devicetype":"huebridge-telegraf-plugin"}'
+  ## The Hue bridges to query (multiple tuples of base url, application key). Use "auto:<bridge id>"
+  ## (or simply "auto" in case of a single bridge) as base url to discover the bridge on the local
+  ## network (see command huebridge-telegraf-plugin discover).
+  ## To create an application key run the following command line for the targeted Hue bridge and
+  ## press the bridge's link button when asked to:
+  ## huebridge-telegraf-plugin pair -bridge <bridge IP or DNS name>
   bridges = [["https://<insert IP or DNS name>", "<insert application key>"]]
   ## The http timeout to use (in seconds)
   # timeout = 10
@@ -52,8 +102,77 @@ func (plugin *HueBridge) SampleConfig() string {
   ## allows a manual assignment. Every sub-array defines an assignment. The 1st element names
   ## the room and the following elements the devices to assign to this room.
   # room_assignments = [["room", "device 1"]]
+  ## How to handle lights whose device is not reachable via Zigbee (e.g. because it has been
+  ## switched off at the wall). Possible values are "report" (report as usual), "tag" (report
+  ## with an additional huebridge_reachable tag) or "skip" (do not report).
+  # unreachable_lights = "report"
+  ## How to handle lights which are part of an active entertainment area (e.g. while streaming
+  ## from a Sync box). Possible values are "report" (report as usual), "tag" (report with an
+  ## additional huebridge_streaming tag) or "skip" (do not report).
+  # streaming_lights = "report"
+  ## How to report the zone membership of the devices. Possible values are "none" (do not report),
+  ## "tag" (report all zones as comma separated huebridge_zone tag) or "series" (report an additional
+  ## series with a huebridge_zone tag for every zone).
+  # zone_membership = "none"
+  ## Additional device tags to add to the device related measurements. Possible values are "model"
+  ## (huebridge_model), "archetype" (huebridge_archetype), "manufacturer" (huebridge_manufacturer)
+  ## and "product" (huebridge_product).
+  # device_tags = []
+  ## The interval (in seconds) at which the device inventory is reported (0 reports it on every gather)
+  # device_info_interval = 3600
+  ## Use the bridge's report timestamps as metric timestamps for the sensor, button, rotary and
+  ## contact measurements. Every reported change is emitted only once in this case.
+  # report_timestamps = false
+  ## Which metrics to emit. Possible values are "all" (emit every metric on every gather) or "changes"
+  ## (emit a metric only if any of it's values has changed since it was last emitted).
+  # emit = "all"
+  ## The interval (in seconds) at which unchanged metrics are emitted nevertheless in "changes" mode
+  ## (0 disables the heartbeat)
+  # heartbeat_interval = 600
+  ## The maximum number of concurrent requests per bridge. The bridges as well as their resources are
+  ## fetched concurrently, this option limits the load put on every bridge (1 disables concurrent requests).
+  # max_concurrent_requests = 4
+  ## Fetch all resources of a bridge with a single request per gather (instead of one request per
+  ## resource type). This reduces the bridge load and reports a consistent snapshot of all resources.
+  # snapshot = false
+  ## How to verify the bridge's TLS certificate. Possible values are "none" (no verification),
+  ## "hue" (verify against the Hue root CA and check the certificate is issued for the bridge ID),
+  ## "ca" (standard verification using the system CAs or tls_ca) or "fingerprint" (verify against
+  ## the SHA-256 fingerprints given in tls_fingerprints).
+  # tls_verify = "none"
+  # tls_fingerprints = []
+  ## Optional TLS config (tls_server_name defines the expected bridge ID in "hue" mode)
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  # tls_server_name = ""
+  ## Enable event driven collection via the bridge's event stream. Instead of polling, every
+  ## change reported by the bridge is turned into a metric as soon as it arrives.
+  # eventstream = false
   ## Enable debug output
   # debug = false
+  ## Alternatively (or additionally) to the bridges option above, bridges can be defined via
+  ## sub-tables, which allow per-bridge settings. Options not set within a sub-table default to
+  ## the corresponding plugin-wide option.
+  # [[inputs.huebridge.bridge]]
+  #   ## The base url (or auto:<bridge id>) and application key of the bridge. The application key
+  #   ## may be a secret-store reference (@{store:key}) or is read from an environment variable
+  #   ## (application_key_env) or a file (application_key_file) instead.
+  #   url = "https://<insert IP or DNS name>"
+  #   application_key = "<insert application key>"
+  #   # application_key_env = "HUEBRIDGE_APPLICATION_KEY"
+  #   # application_key_file = "/etc/telegraf/huebridge.key"
+  #   ## An optional name for the bridge (reported via the huebridge_name tag)
+  #   # name = ""
+  #   # timeout = 10
+  #   ## The resource types to query (e.g. "light", "temperature", "motion", ...). Empty queries all.
+  #   # resources = []
+  #   ## Additional room assignments for this bridge (taking precedence over the plugin-wide ones)
+  #   # room_assignments = [["room", "device 1"]]
+  #   # tls_verify = "none"
+  #   # tls_fingerprints = []
+  #   # tls_ca = "/etc/telegraf/ca.pem"
+  #   # tls_server_name = ""
  `
 }
 
@@ -62,128 +181,721 @@ func (plugin *HueBridge) Description() string {
 }
 
 func (plugin *HueBridge) Gather(a telegraf.Accumulator) error {
-	if len(plugin.Bridges) == 0 {
-		return errors.New("huebridge: Empty bridge list")
+	if plugin.EventStream {
+		// metrics are reported by the running event streams, only the bridge stats and the heartbeats
+		// are reported per gather
+		plugin.gatherEventStreamBridges(a)
+		plugin.emitHeartbeats(a)
+		return nil
 	}
-	for _, bridge := range plugin.Bridges {
-		if len(bridge) != 2 {
-			return fmt.Errorf("huebridge: Invalid bridge entry: %s", bridge)
+	gatherStart := time.Now()
+	bridgeTasks := make([]func(), 0, len(plugin.bridges))
+	for _, bridge := range plugin.bridges {
+		bridge := bridge
+		bridgeTasks = append(bridgeTasks, func() {
+			a.AddError(plugin.processConfiguredBridge(a, bridge))
+		})
+	}
+	plugin.runConcurrently(bridgeTasks...)
+	plugin.pruneEmittedSnapshots("", gatherStart)
+	return nil
+}
+
+// The possible options for handling lights in a special state (e.g. unreachable)
+const lightsReport = "report"
+const lightsTag = "tag"
+const lightsSkip = "skip"
+
+const emitAll = "all"
+const emitChanges = "changes"
+
+const zoneMembershipNone = "none"
+const zoneMembershipTag = "tag"
+const zoneMembershipSeries = "series"
+
+var deviceTagNames = map[string]string{
+	"model":        "huebridge_model",
+	"archetype":    "huebridge_archetype",
+	"manufacturer": "huebridge_manufacturer",
+	"product":      "huebridge_product",
+}
+
+func (plugin *HueBridge) checkConfig() error {
+	switch plugin.UnreachableLights {
+	case "", lightsReport, lightsTag, lightsSkip:
+	default:
+		return fmt.Errorf("huebridge: Invalid unreachable_lights option: %s", plugin.UnreachableLights)
+	}
+	switch plugin.StreamingLights {
+	case "", lightsReport, lightsTag, lightsSkip:
+	default:
+		return fmt.Errorf("huebridge: Invalid streaming_lights option: %s", plugin.StreamingLights)
+	}
+	switch plugin.ZoneMembership {
+	case "", zoneMembershipNone, zoneMembershipTag, zoneMembershipSeries:
+	default:
+		return fmt.Errorf("huebridge: Invalid zone_membership option: %s", plugin.ZoneMembership)
+	}
+	for _, deviceTag := range plugin.DeviceTags {
+		if deviceTagNames[deviceTag] == "" {
+			return fmt.Errorf("huebridge: Invalid device_tags option: %s", deviceTag)
 		}
-		bridgeUrl := bridge[0]
-		username := bridge[1]
-		a.AddError(plugin.processBridge(a, bridgeUrl, username))
+	}
+	switch plugin.Emit {
+	case "", emitAll, emitChanges:
+	default:
+		return fmt.Errorf("huebridge: Invalid emit option: %s", plugin.Emit)
+	}
+	if plugin.MaxConcurrentRequests < 1 {
+		return fmt.Errorf("huebridge: Invalid max_concurrent_requests option: %d", plugin.MaxConcurrentRequests)
 	}
 	return nil
 }
 
+func (plugin *HueBridge) processConfiguredBridge(a telegraf.Accumulator, bridge *BridgeConfig) error {
+	applicationKey, err := bridge.applicationKey()
+	if err != nil {
+		return err
+	}
+	resolvedBridgeUrl, err := plugin.activateBridge(bridge)
+	if err != nil {
+		return err
+	}
+	err = plugin.processBridge(a, resolvedBridgeUrl, applicationKey)
+	if err != nil && resolvedBridgeUrl != bridge.Url {
+		// the bridge's address may have changed, re-discover during the next gather
+		plugin.forgetDiscoveredBridges()
+	}
+	return err
+}
+
 func (plugin *HueBridge) processBridge(a telegraf.Accumulator, bridgeUrl string, applicationKey string) error {
 	if plugin.Debug {
 		plugin.Log.Infof("Processing bridge: %s", bridgeUrl)
 	}
-	devices, err := plugin.fetchDevices(a, bridgeUrl, applicationKey)
-	if err != nil {
-		return err
+	if plugin.Snapshot {
+		err := plugin.takeSnapshot(bridgeUrl, applicationKey)
+		if err != nil {
+			// the bridge stats are reported nevertheless, as they show the bridge's health
+			plugin.evalBridge(plugin.newBridgeAccumulator(a, bridgeUrl, nil), bridgeUrl, applicationKey, nil, nil)
+			return err
+		}
+		defer plugin.releaseSnapshot(bridgeUrl)
 	}
-	rooms, err := plugin.fetchRooms(a, bridgeUrl, applicationKey)
+	bridge, err := plugin.fetchBridge(a, bridgeUrl, applicationKey)
 	if err != nil {
-		return err
+		a.AddError(fmt.Errorf("failed to eval bridge (cause: %w)", err))
+	}
+	a = plugin.newBridgeAccumulator(a, bridgeUrl, bridge)
+	var devices *devicesList
+	var rooms *roomsList
+	var zones *roomsList
+	var devicesErr, roomsErr, zonesErr error
+	plugin.runConcurrently(
+		func() { devices, devicesErr = plugin.fetchDevices(a, bridgeUrl, applicationKey) },
+		func() { rooms, roomsErr = plugin.fetchRooms(a, bridgeUrl, applicationKey) },
+		func() { zones, zonesErr = plugin.fetchZones(a, bridgeUrl, applicationKey) },
+	)
+	err = errors.Join(devicesErr, roomsErr, zonesErr)
+	if err == nil {
+		plugin.processBridgeResources(a, bridgeUrl, applicationKey, devices, rooms, zones)
+	}
+	plugin.evalBridge(a, bridgeUrl, applicationKey, bridge, devices)
+	return err
+}
+
+// newBridgeAccumulator creates the accumulator used for all metrics of a bridge. If the bridge data
+// is not available (e.g. because the bridge is not responding), the last known bridge ID is used.
+func (plugin *HueBridge) newBridgeAccumulator(a telegraf.Accumulator, bridgeUrl string, bridge *bridgeData) telegraf.Accumulator {
+	plugin.stateLock.Lock()
+	if plugin.bridgeIds == nil {
+		plugin.bridgeIds = make(map[string]string)
+	}
+	if bridge != nil {
+		plugin.bridgeIds[bridgeUrl] = bridge.BridgeId
+	}
+	bridgeId := plugin.bridgeIds[bridgeUrl]
+	plugin.stateLock.Unlock()
+	return &bridgeAccumulator{Accumulator: a, plugin: plugin, bridgeId: bridgeId, bridgeName: plugin.bridgeConfig(bridgeUrl).Name}
+}
+
+func (plugin *HueBridge) processBridgeResources(a telegraf.Accumulator, bridgeUrl string, applicationKey string, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	if plugin.isDeviceInfoDue(bridgeUrl) {
+		plugin.evalDeviceInfos(a, bridgeUrl, devices, rooms, zones)
+	}
+	// the resources are fetched concurrently (limited by max_concurrent_requests), only the
+	// lights depend on other resources
+	plugin.runConcurrently(
+		func() {
+			plugin.processLightResources(a, bridgeUrl, applicationKey, devices, rooms, zones)
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "temperature") {
+				return
+			}
+			temperatures, err := plugin.fetchTemperatures(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalTemperatures(a, bridgeUrl, temperatures, devices, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval temperatures (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "light_level") {
+				return
+			}
+			lightLevels, err := plugin.fetchLightLevels(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalLightLevels(a, bridgeUrl, lightLevels, devices, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval light levels (cause: %w)", err))
+			}
+		},
+		func() {
+			var motionAreas *motionAreaConfigurationsList
+			for _, motionType := range motionTypes {
+				if !plugin.isResourceEnabled(bridgeUrl, motionType) {
+					continue
+				}
+				motions, err := plugin.fetchMotions(a, bridgeUrl, applicationKey, motionType)
+				if err == nil {
+					// the motion areas are only fetched if needed (MotionAware capable bridges)
+					if motionAreas == nil && motions.hasMotionAreaOwner() {
+						motionAreas, err = plugin.fetchMotionAreaConfigurations(a, bridgeUrl, applicationKey)
+						if err != nil {
+							a.AddError(fmt.Errorf("failed to eval motion area configurations (cause: %w)", err))
+						}
+					}
+					plugin.evalMotions(a, bridgeUrl, motionType, motions, motionAreas, devices, rooms, zones)
+				} else if motionType == "motion" || !errors.Is(err, errResourceNotFound) {
+					// only the motion resource is available on all bridges
+					a.AddError(fmt.Errorf("failed to eval %s (cause: %w)", strings.ReplaceAll(motionType, "_", " "), err))
+				}
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "grouped_motion") {
+				return
+			}
+			groupedMotions, err := plugin.fetchGroupedMotions(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalGroupedMotions(a, bridgeUrl, groupedMotions, rooms, zones)
+			} else if !errors.Is(err, errResourceNotFound) {
+				a.AddError(fmt.Errorf("failed to eval grouped motions (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "grouped_light_level") {
+				return
+			}
+			groupedLightLevels, err := plugin.fetchGroupedLightLevels(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalGroupedLightLevels(a, bridgeUrl, groupedLightLevels, rooms, zones)
+			} else if !errors.Is(err, errResourceNotFound) {
+				a.AddError(fmt.Errorf("failed to eval grouped light levels (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "device_power") {
+				return
+			}
+			devicePowers, err := plugin.fetchDevicePowers(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalDevicePowers(a, bridgeUrl, devicePowers, devices)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval motions (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "button") {
+				return
+			}
+			buttons, err := plugin.fetchButtons(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalButtons(a, bridgeUrl, buttons, devices, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval buttons (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "relative_rotary") {
+				return
+			}
+			relativeRotaries, err := plugin.fetchRelativeRotaries(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalRelativeRotaries(a, bridgeUrl, relativeRotaries, devices, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval relative rotaries (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "contact") {
+				return
+			}
+			contacts, err := plugin.fetchContacts(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalContacts(a, bridgeUrl, contacts, devices, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval contacts (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "tamper") {
+				return
+			}
+			tampers, err := plugin.fetchTampers(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalTampers(a, bridgeUrl, tampers, devices, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval tampers (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "device_software_update") {
+				return
+			}
+			deviceSoftwareUpdates, err := plugin.fetchDeviceSoftwareUpdates(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalDeviceSoftwareUpdates(a, bridgeUrl, deviceSoftwareUpdates, devices)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval device software updates (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "scene") {
+				return
+			}
+			scenes, err := plugin.fetchScenes(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalScenes(a, bridgeUrl, scenes, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval scenes (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "smart_scene") {
+				return
+			}
+			smartScenes, err := plugin.fetchSmartScenes(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalSmartScenes(a, bridgeUrl, smartScenes, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval smart scenes (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "behavior_instance") {
+				return
+			}
+			behaviorInstances, err := plugin.fetchBehaviorInstances(a, bridgeUrl, applicationKey)
+			if err == nil {
+				behaviorScripts, err := plugin.fetchBehaviorScripts(a, bridgeUrl, applicationKey)
+				if err == nil {
+					plugin.evalBehaviorInstances(a, bridgeUrl, behaviorInstances, behaviorScripts)
+				} else {
+					a.AddError(fmt.Errorf("failed to eval behavior scripts (cause: %w)", err))
+				}
+			} else {
+				a.AddError(fmt.Errorf("failed to eval behavior instances (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "geolocation") {
+				return
+			}
+			geolocations, err := plugin.fetchGeolocations(a, bridgeUrl, applicationKey)
+			if err == nil {
+				geofenceClients, err := plugin.fetchGeofenceClients(a, bridgeUrl, applicationKey)
+				if err == nil {
+					plugin.evalPresence(a, bridgeUrl, geolocations, geofenceClients)
+				} else {
+					a.AddError(fmt.Errorf("failed to eval geofence clients (cause: %w)", err))
+				}
+			} else {
+				a.AddError(fmt.Errorf("failed to eval geolocations (cause: %w)", err))
+			}
+		},
+	)
+}
+
+func (plugin *HueBridge) processLightResources(a telegraf.Accumulator, bridgeUrl string, applicationKey string, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	// connectivity and entertainment configurations are evaluated first, as they determine the
+	// reachability respectively the streaming state of the lights
+	plugin.runConcurrently(
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "zigbee_connectivity") {
+				return
+			}
+			zigbeeConnectivities, err := plugin.fetchZigbeeConnectivities(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalZigbeeConnectivities(a, bridgeUrl, zigbeeConnectivities, devices, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval zigbee connectivities (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "zgp_connectivity") {
+				return
+			}
+			zgpConnectivities, err := plugin.fetchZgpConnectivities(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalZgpConnectivities(a, bridgeUrl, zgpConnectivities, devices, rooms, zones)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval zgp connectivities (cause: %w)", err))
+			}
+		},
+		func() {
+			if !plugin.isResourceEnabled(bridgeUrl, "entertainment_configuration") {
+				return
+			}
+			entertainmentConfigurations, err := plugin.fetchEntertainmentConfigurations(a, bridgeUrl, applicationKey)
+			if err == nil {
+				plugin.evalEntertainmentConfigurations(a, bridgeUrl, entertainmentConfigurations, devices)
+			} else {
+				a.AddError(fmt.Errorf("failed to eval entertainment configurations (cause: %w)", err))
+			}
+		},
+	)
+	lightsEnabled := plugin.isResourceEnabled(bridgeUrl, "light")
+	groupedLightsEnabled := plugin.isResourceEnabled(bridgeUrl, "grouped_light")
+	if !lightsEnabled && !groupedLightsEnabled {
+		return
 	}
+	// the lights are also needed to evaluate the grouped lights
 	lights, err := plugin.fetchLights(a, bridgeUrl, applicationKey)
 	if err == nil {
-		plugin.evalLights(a, bridgeUrl, lights, devices, rooms)
+		if lightsEnabled {
+			plugin.evalLights(a, bridgeUrl, lights, devices, rooms, zones)
+		}
 	} else {
 		a.AddError(fmt.Errorf("failed to eval lights (cause: %w)", err))
 	}
-	temperatures, err := plugin.fetchTemperatures(a, bridgeUrl, applicationKey)
-	if err == nil {
-		plugin.evalTemperatures(a, bridgeUrl, temperatures, devices, rooms)
-	} else {
-		a.AddError(fmt.Errorf("failed to eval temperatures (cause: %w)", err))
+	if groupedLightsEnabled {
+		groupedLights, err := plugin.fetchGroupedLights(a, bridgeUrl, applicationKey)
+		if err == nil {
+			plugin.evalGroupedLights(a, bridgeUrl, groupedLights, lights, rooms, zones)
+		} else {
+			a.AddError(fmt.Errorf("failed to eval grouped lights (cause: %w)", err))
+		}
 	}
-	lightLevels, err := plugin.fetchLightLevels(a, bridgeUrl, applicationKey)
-	if err == nil {
-		plugin.evalLightLevels(a, bridgeUrl, lightLevels, devices, rooms)
-	} else {
-		a.AddError(fmt.Errorf("failed to eval light levels (cause: %w)", err))
+}
+
+func (plugin *HueBridge) runConcurrently(tasks ...func()) {
+	var running sync.WaitGroup
+	for _, task := range tasks {
+		running.Add(1)
+		go func(task func()) {
+			defer running.Done()
+			task()
+		}(task)
 	}
-	motions, err := plugin.fetchMotions(a, bridgeUrl, applicationKey)
+	running.Wait()
+}
+
+func (plugin *HueBridge) evalBridge(a telegraf.Accumulator, bridgeUrl string, applicationKey string, bridge *bridgeData, devices *devicesList) {
+	tags := make(map[string]string)
+	tags["huebridge_url"] = bridgeUrl
+	fields := make(map[string]interface{})
+	bridgeConfig, err := plugin.fetchBridgeConfig(a, bridgeUrl)
 	if err == nil {
-		plugin.evalMotions(a, bridgeUrl, motions, devices, rooms)
+		fields["name"] = bridgeConfig.Name
+		fields["model_id"] = bridgeConfig.ModelId
+		fields["software_version"] = bridgeConfig.SwVersion
+		fields["api_version"] = bridgeConfig.ApiVersion
+		fields["mac_address"] = bridgeConfig.Mac
 	} else {
-		a.AddError(fmt.Errorf("failed to eval motions (cause: %w)", err))
+		a.AddError(fmt.Errorf("failed to eval bridge config (cause: %w)", err))
 	}
-	devicePowers, err := plugin.fetchDevicePowers(a, bridgeUrl, applicationKey)
-	if err == nil {
-		plugin.evalDevicePowers(a, bridgeUrl, devicePowers, devices)
-	} else {
-		a.AddError(fmt.Errorf("failed to eval motions (cause: %w)", err))
+	if bridge != nil && devices != nil {
+		zigbeeConnectivity, err := plugin.fetchBridgeZigbeeConnectivity(a, bridgeUrl, applicationKey, bridge, devices)
+		if err == nil {
+			if zigbeeConnectivity != nil && zigbeeConnectivity.Channel != nil {
+				zigbeeChannel, err := strconv.Atoi(strings.TrimPrefix(zigbeeConnectivity.Channel.Value, "channel_"))
+				if err == nil {
+					fields["zigbee_channel"] = zigbeeChannel
+				}
+			}
+		} else {
+			a.AddError(fmt.Errorf("failed to eval bridge zigbee connectivity (cause: %w)", err))
+		}
 	}
-	return nil
+	requestStats := plugin.resetRequestStats(bridgeUrl)
+	if requestStats.errors > 0 {
+		// the bridge may have been restarted (e.g. due to a firmware update), re-fetch it's config
+		plugin.forgetBridgeConfig(bridgeUrl)
+	}
+	fields["requests"] = requestStats.requests
+	fields["request_errors"] = requestStats.errors
+	if requestStats.requests > 0 {
+		fields["request_latency_ms"] = float64(requestStats.latency.Microseconds()) / float64(requestStats.requests) / 1000.0
+	}
+	a.AddCounter("huebridge_bridge", fields, tags)
+}
+
+func (plugin *HueBridge) evalDeviceInfos(a telegraf.Accumulator, bridgeUrl string, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	for _, device := range devices.Data {
+		deviceLink := &resourceLink{Rid: device.Id, Rtype: "device"}
+		deviceName, deviceRoomName := deviceLink.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		tags["huebridge_room"] = deviceRoomName
+		tags["huebridge_device"] = deviceName
+		plugin.addDeviceTags(tags, deviceLink, devices)
+		fields := make(map[string]interface{})
+		fields["archetype"] = device.Metadata.Archetype
+		fields["model_id"] = device.ProductData.ModelId
+		fields["manufacturer_name"] = device.ProductData.ManufacturerName
+		fields["product_name"] = device.ProductData.ProductName
+		fields["product_archetype"] = device.ProductData.ProductArchetype
+		fields["software_version"] = device.ProductData.SoftwareVersion
+		if device.ProductData.Certified {
+			fields["certified"] = 1
+		} else {
+			fields["certified"] = 0
+		}
+		plugin.addResourceMetric(a, "huebridge_device_info", fields, tags, deviceLink.getZoneNames(device.Id, devices, zones))
+	}
+}
+
+func (plugin *HueBridge) isDeviceInfoDue(bridgeUrl string) bool {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.deviceInfoReported == nil {
+		plugin.deviceInfoReported = make(map[string]time.Time)
+	}
+	now := time.Now()
+	lastReported, reported := plugin.deviceInfoReported[bridgeUrl]
+	if reported && now.Before(lastReported.Add(time.Duration(plugin.DeviceInfoInterval)*time.Second)) {
+		return false
+	}
+	plugin.deviceInfoReported[bridgeUrl] = now
+	return true
 }
 
-func (plugin *HueBridge) evalLights(a telegraf.Accumulator, bridgeUrl string, lights *lightsStatus, devices *devicesList, rooms *roomsList) {
+func (plugin *HueBridge) evalLights(a telegraf.Accumulator, bridgeUrl string, lights *lightsStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
 	for _, light := range lights.Data {
-		lightDeviceName, lightRoomName := light.Owner.getDeviceAndRoomName(devices, rooms, plugin.RoomAssignments)
+		reachable := plugin.isDeviceReachable(light.Owner.Rid)
+		if !reachable && plugin.UnreachableLights == lightsSkip {
+			continue
+		}
+		streaming := plugin.isLightStreaming(bridgeUrl, light.Id)
+		if streaming && plugin.StreamingLights == lightsSkip {
+			continue
+		}
+		lightDeviceName, lightRoomName := light.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
 		tags := make(map[string]string)
 		tags["huebridge_url"] = bridgeUrl
 		tags["huebridge_room"] = lightRoomName
 		tags["huebridge_device"] = lightDeviceName
+		plugin.addDeviceTags(tags, &light.Owner, devices)
+		if plugin.UnreachableLights == lightsTag {
+			tags["huebridge_reachable"] = strconv.FormatBool(reachable)
+		}
+		if plugin.StreamingLights == lightsTag {
+			tags["huebridge_streaming"] = strconv.FormatBool(streaming)
+		}
 		fields := make(map[string]interface{})
 		if light.On.On {
 			fields["on"] = 1
 		} else {
 			fields["on"] = 0
 		}
-		a.AddCounter("huebridge_light", fields, tags)
+		if light.Mode != "" {
+			fields["mode"] = light.Mode
+		}
+		brightness := 100.0
+		if light.Dimming != nil {
+			brightness = light.Dimming.Brightness
+			fields["brightness"] = brightness
+		}
+		if light.ColorTemperature != nil {
+			if light.ColorTemperature.MirekValid {
+				fields["color_temperature_mirek_valid"] = 1
+				fields["color_temperature_mirek"] = light.ColorTemperature.Mirek
+				if light.ColorTemperature.Mirek > 0 {
+					fields["color_temperature_kelvin"] = 1000000 / light.ColorTemperature.Mirek
+				}
+			} else {
+				fields["color_temperature_mirek_valid"] = 0
+			}
+		}
+		if light.Color != nil {
+			fields["color_x"] = light.Color.XY.X
+			fields["color_y"] = light.Color.XY.Y
+			if light.Color.GamutType != "" {
+				fields["color_gamut_type"] = light.Color.GamutType
+			}
+			fields["color_hex"] = xyToRGBHex(light.Color.XY.X, light.Color.XY.Y, brightness)
+		}
+		if light.Dynamics != nil {
+			fields["dynamics_status"] = light.Dynamics.Status
+		}
+		if light.Effects != nil {
+			fields["effects_status"] = light.Effects.Status
+		}
+		plugin.addResourceMetric(a, "huebridge_light", fields, tags, light.Owner.getZoneNames(light.Id, devices, zones))
+	}
+}
+
+func (plugin *HueBridge) evalGroupedLights(a telegraf.Accumulator, bridgeUrl string, groupedLights *groupedLightsStatus, lights *lightsStatus, rooms *roomsList, zones *roomsList) {
+	for _, groupedLight := range groupedLights.Data {
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		group := groupedLight.Owner.addGroupTag(tags, rooms, zones)
+		if group == nil {
+			// e.g. the bridge_home group covering all lights
+			continue
+		}
+		fields := make(map[string]interface{})
+		if groupedLight.On.On {
+			fields["on"] = 1
+		} else {
+			fields["on"] = 0
+		}
+		if groupedLight.Dimming != nil {
+			fields["brightness"] = groupedLight.Dimming.Brightness
+		}
+		if lights != nil {
+			lightCount := 0
+			lightOnCount := 0
+			for _, light := range lights.Data {
+				if group.containsLight(&light) {
+					lightCount++
+					if light.On.On {
+						lightOnCount++
+					}
+				}
+			}
+			fields["lights"] = lightCount
+			fields["lights_on"] = lightOnCount
+		}
+		a.AddCounter("huebridge_grouped_light", fields, tags)
 	}
 }
 
-func (plugin *HueBridge) evalTemperatures(a telegraf.Accumulator, bridgeUrl string, temperatures *temperaturesStatus, devices *devicesList, rooms *roomsList) {
+func (plugin *HueBridge) evalTemperatures(a telegraf.Accumulator, bridgeUrl string, temperatures *temperaturesStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
 	for _, temperature := range temperatures.Data {
 		if temperature.Enabled && temperature.Temperature.TemperatureValid {
-			temperatureDeviceName, temperatureRoomName := temperature.Owner.getDeviceAndRoomName(devices, rooms, plugin.RoomAssignments)
+			temperatureDeviceName, temperatureRoomName := temperature.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
 			tags := make(map[string]string)
 			tags["huebridge_url"] = bridgeUrl
 			tags["huebridge_room"] = temperatureRoomName
 			tags["huebridge_device"] = temperatureDeviceName
+			plugin.addDeviceTags(tags, &temperature.Owner, devices)
 			fields := make(map[string]interface{})
 			fields["temperature"] = temperature.Temperature.Temperature
-			a.AddCounter("huebridge_temperature", fields, tags)
+			var changed string
+			if temperature.Temperature.TemperatureReport != nil {
+				changed = temperature.Temperature.TemperatureReport.Changed
+			}
+			if t, emit := plugin.reportTime(temperature.Id, changed); emit {
+				plugin.addResourceMetric(a, "huebridge_temperature", fields, tags, temperature.Owner.getZoneNames(temperature.Id, devices, zones), t...)
+			}
 		}
 	}
 }
 
-func (plugin *HueBridge) evalLightLevels(a telegraf.Accumulator, bridgeUrl string, lightLevels *lightLevelsStatus, devices *devicesList, rooms *roomsList) {
+func (plugin *HueBridge) evalLightLevels(a telegraf.Accumulator, bridgeUrl string, lightLevels *lightLevelsStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
 	for _, lightLevel := range lightLevels.Data {
 		if lightLevel.Enabled && lightLevel.Light.LightLevelValid {
-			lightLevelDeviceName, lightLevelRoomName := lightLevel.Owner.getDeviceAndRoomName(devices, rooms, plugin.RoomAssignments)
+			lightLevelDeviceName, lightLevelRoomName := lightLevel.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
 			tags := make(map[string]string)
 			tags["huebridge_url"] = bridgeUrl
 			tags["huebridge_room"] = lightLevelRoomName
 			tags["huebridge_device"] = lightLevelDeviceName
+			plugin.addDeviceTags(tags, &lightLevel.Owner, devices)
 			fields := make(map[string]interface{})
 			fields["light_level"] = lightLevel.Light.LightLevel
-			fields["light_level_lux"] = math.Pow(10.0, (float64(lightLevel.Light.LightLevel)-1.0)/10000.0)
-			a.AddCounter("huebridge_light_level", fields, tags)
+			fields["light_level_lux"] = lightLevelToLux(lightLevel.Light.LightLevel)
+			var changed string
+			if lightLevel.Light.LightLevelReport != nil {
+				changed = lightLevel.Light.LightLevelReport.Changed
+			}
+			if t, emit := plugin.reportTime(lightLevel.Id, changed); emit {
+				plugin.addResourceMetric(a, "huebridge_light_level", fields, tags, lightLevel.Owner.getZoneNames(lightLevel.Id, devices, zones), t...)
+			}
+		}
+	}
+}
+
+func lightLevelToLux(lightLevel float32) float64 {
+	return math.Pow(10.0, (float64(lightLevel)-1.0)/10000.0)
+}
+
+func (plugin *HueBridge) evalGroupedLightLevels(a telegraf.Accumulator, bridgeUrl string, groupedLightLevels *groupedLightLevelsStatus, rooms *roomsList, zones *roomsList) {
+	for _, groupedLightLevel := range groupedLightLevels.Data {
+		if groupedLightLevel.Enabled && groupedLightLevel.Light.LightLevelReport != nil {
+			tags := make(map[string]string)
+			tags["huebridge_url"] = bridgeUrl
+			if groupedLightLevel.Owner.addGroupTag(tags, rooms, zones) == nil {
+				// e.g. the bridge_home group covering all sensors
+				continue
+			}
+			fields := make(map[string]interface{})
+			fields["light_level"] = groupedLightLevel.Light.LightLevelReport.LightLevel
+			fields["light_level_lux"] = lightLevelToLux(groupedLightLevel.Light.LightLevelReport.LightLevel)
+			if t, emit := plugin.reportTime(groupedLightLevel.Id, groupedLightLevel.Light.LightLevelReport.Changed); emit {
+				a.AddCounter("huebridge_grouped_light_level", fields, tags, t...)
+			}
 		}
 	}
 }
 
-func (plugin *HueBridge) evalMotions(a telegraf.Accumulator, bridgeUrl string, motions *motionsStatus, devices *devicesList, rooms *roomsList) {
+var motionTypes = []string{"motion", "camera_motion", "convenience_area_motion", "security_area_motion"}
+
+func (plugin *HueBridge) evalMotions(a telegraf.Accumulator, bridgeUrl string, motionType string, motions *motionsStatus, motionAreas *motionAreaConfigurationsList, devices *devicesList, rooms *roomsList, zones *roomsList) {
 	for _, motion := range motions.Data {
 		if motion.Enabled && motion.Motion.MotionValid {
-			motionDeviceName, motionRoomName := motion.Owner.getDeviceAndRoomName(devices, rooms, plugin.RoomAssignments)
+			motionDeviceName, motionRoomName := motion.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
 			tags := make(map[string]string)
 			tags["huebridge_url"] = bridgeUrl
 			tags["huebridge_room"] = motionRoomName
 			tags["huebridge_device"] = motionDeviceName
+			tags["huebridge_motion_source"] = motionType
+			if motion.Owner.Rtype == "motion_area_configuration" {
+				// the MotionAware areas are not owned by a device, hence they are identified by the area name
+				tags["huebridge_motion_area"] = motionAreas.getMotionAreaName(motion.Owner.Rid)
+			}
+			plugin.addDeviceTags(tags, &motion.Owner, devices)
 			fields := make(map[string]interface{})
 			if motion.Motion.Motion {
 				fields["motion"] = 1
 			} else {
 				fields["motion"] = 0
 			}
-			a.AddCounter("huebridge_motion", fields, tags)
+			if motion.Sensitivity != nil {
+				fields["sensitivity"] = motion.Sensitivity.Sensitivity
+				fields["sensitivity_max"] = motion.Sensitivity.SensitivityMax
+			}
+			var changed string
+			if motion.Motion.MotionReport != nil {
+				changed = motion.Motion.MotionReport.Changed
+			}
+			if t, emit := plugin.reportTime(motion.Id, changed); emit {
+				plugin.addResourceMetric(a, "huebridge_motion", fields, tags, motion.Owner.getZoneNames(motion.Id, devices, zones), t...)
+			}
+		}
+	}
+}
+
+func (plugin *HueBridge) evalGroupedMotions(a telegraf.Accumulator, bridgeUrl string, groupedMotions *groupedMotionsStatus, rooms *roomsList, zones *roomsList) {
+	for _, groupedMotion := range groupedMotions.Data {
+		if groupedMotion.Enabled && groupedMotion.Motion.MotionReport != nil {
+			tags := make(map[string]string)
+			tags["huebridge_url"] = bridgeUrl
+			if groupedMotion.Owner.addGroupTag(tags, rooms, zones) == nil {
+				// e.g. the bridge_home group covering all sensors
+				continue
+			}
+			fields := make(map[string]interface{})
+			if groupedMotion.Motion.MotionReport.Motion {
+				fields["motion"] = 1
+			} else {
+				fields["motion"] = 0
+			}
+			if t, emit := plugin.reportTime(groupedMotion.Id, groupedMotion.Motion.MotionReport.Changed); emit {
+				a.AddCounter("huebridge_grouped_motion", fields, tags, t...)
+			}
 		}
 	}
 }
@@ -194,82 +906,1024 @@ func (plugin *HueBridge) evalDevicePowers(a telegraf.Accumulator, bridgeUrl stri
 		tags := make(map[string]string)
 		tags["huebridge_url"] = bridgeUrl
 		tags["huebridge_device"] = devicePowerDeviceName
+		plugin.addDeviceTags(tags, &devicePower.Owner, devices)
 		fields := make(map[string]interface{})
 		fields["battery_level"] = devicePower.PowerState.BatteryLevel
 		a.AddCounter("huebridge_device_power", fields, tags)
 	}
 }
 
-type lightsStatus struct {
-	Data []lightData `json:"data"`
+func (plugin *HueBridge) evalDeviceSoftwareUpdates(a telegraf.Accumulator, bridgeUrl string, deviceSoftwareUpdates *deviceSoftwareUpdatesStatus, devices *devicesList) {
+	for _, deviceSoftwareUpdate := range deviceSoftwareUpdates.Data {
+		deviceSoftwareUpdateDeviceName := deviceSoftwareUpdate.Owner.getDeviceName(devices)
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		tags["huebridge_device"] = deviceSoftwareUpdateDeviceName
+		plugin.addDeviceTags(tags, &deviceSoftwareUpdate.Owner, devices)
+		fields := make(map[string]interface{})
+		fields["state"] = deviceSoftwareUpdate.State
+		fields["state_duration"] = int64(plugin.trackState(deviceSoftwareUpdate.Id, deviceSoftwareUpdate.State).Seconds())
+		fields["problems"] = strings.Join(deviceSoftwareUpdate.Problems, ",")
+		a.AddCounter("huebridge_software_update", fields, tags)
+	}
+}
+
+type emittedSnapshot struct {
+	measurement string
+	tags        map[string]string
+	fields      map[string]interface{}
+	emitted     time.Time
+	seen        time.Time
+}
+
+// changeIgnoredFields lists the fields changing on every gather respectively the report timestamp
+// fields, which therefore are not considered when checking a metric for changes
+var changeIgnoredFields = map[string]bool{
+	"state_duration": true,
+	"updated":        true,
+	"changed":        true,
+}
+
+// isMetricDue checks whether a metric has to be emitted according to the configured emit mode. In
+// "changes" mode the last emitted snapshot of every series is kept and compared to the current fields.
+func (plugin *HueBridge) isMetricDue(measurement string, fields map[string]interface{}, tags map[string]string) bool {
+	if plugin.Emit != emitChanges || measurement == "huebridge_bridge" {
+		// the bridge stats contain the per gather request stats and are always emitted
+		return true
+	}
+	tagKeys := make([]string, 0, len(tags))
+	for tagKey := range tags {
+		tagKeys = append(tagKeys, tagKey)
+	}
+	slices.Sort(tagKeys)
+	var seriesKey strings.Builder
+	seriesKey.WriteString(measurement)
+	for _, tagKey := range tagKeys {
+		seriesKey.WriteString(",")
+		seriesKey.WriteString(tagKey)
+		seriesKey.WriteString("=")
+		seriesKey.WriteString(tags[tagKey])
+	}
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.emittedSnapshots == nil {
+		plugin.emittedSnapshots = make(map[string]*emittedSnapshot)
+	}
+	now := time.Now()
+	snapshot := plugin.emittedSnapshots[seriesKey.String()]
+	if snapshot != nil && !isFieldsChanged(snapshot.fields, fields) && (plugin.HeartbeatInterval <= 0 || now.Sub(snapshot.emitted) < time.Duration(plugin.HeartbeatInterval)*time.Second) {
+		snapshot.seen = now
+		return false
+	}
+	plugin.emittedSnapshots[seriesKey.String()] = &emittedSnapshot{measurement: measurement, tags: tags, fields: fields, emitted: now, seen: now}
+	return true
+}
+
+// pruneEmittedSnapshots drops the snapshots of all series (of the given bridge url or all bridges) which
+// have not been seen since the given time (e.g. because the device has been deleted). Otherwise they
+// would be kept forever and re-emitted by the heartbeat.
+func (plugin *HueBridge) pruneEmittedSnapshots(bridgeUrl string, since time.Time) {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	for seriesKey, snapshot := range plugin.emittedSnapshots {
+		if snapshot.seen.Before(since) && (bridgeUrl == "" || snapshot.tags["huebridge_url"] == bridgeUrl) {
+			delete(plugin.emittedSnapshots, seriesKey)
+		}
+	}
+}
+
+// emitHeartbeats re-emits the last snapshot of every series whose heartbeat interval has elapsed. In
+// event stream mode metrics are only emitted on change, hence the heartbeat is driven by the gather cycle.
+func (plugin *HueBridge) emitHeartbeats(a telegraf.Accumulator) {
+	if plugin.Emit != emitChanges || plugin.HeartbeatInterval <= 0 {
+		return
+	}
+	plugin.stateLock.Lock()
+	now := time.Now()
+	dueSnapshots := make([]*emittedSnapshot, 0)
+	for _, snapshot := range plugin.emittedSnapshots {
+		if now.Sub(snapshot.emitted) >= time.Duration(plugin.HeartbeatInterval)*time.Second {
+			snapshot.emitted = now
+			dueSnapshots = append(dueSnapshots, snapshot)
+		}
+	}
+	plugin.stateLock.Unlock()
+	for _, snapshot := range dueSnapshots {
+		tags := make(map[string]string)
+		for key, value := range snapshot.tags {
+			tags[key] = value
+		}
+		a.AddCounter(snapshot.measurement, snapshot.fields, tags)
+	}
+}
+
+func isFieldsChanged(fields1 map[string]interface{}, fields2 map[string]interface{}) bool {
+	if len(fields1) != len(fields2) {
+		return true
+	}
+	for key, value1 := range fields1 {
+		if changeIgnoredFields[key] {
+			continue
+		}
+		value2, exists := fields2[key]
+		if !exists || value1 != value2 {
+			return true
+		}
+	}
+	return false
+}
+
+type stateChange struct {
+	state string
+	since time.Time
+}
+
+// trackState determines for how long a resource has been in it's current state (as far as observed by the plugin)
+func (plugin *HueBridge) trackState(resourceId string, state string) time.Duration {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.stateChanges == nil {
+		plugin.stateChanges = make(map[string]*stateChange)
+	}
+	now := time.Now()
+	change := plugin.stateChanges[resourceId]
+	if change == nil || change.state != state {
+		change = &stateChange{state: state, since: now}
+		plugin.stateChanges[resourceId] = change
+	}
+	return now.Sub(change.since)
+}
+
+func (plugin *HueBridge) evalScenes(a telegraf.Accumulator, bridgeUrl string, scenes *scenesStatus, rooms *roomsList, zones *roomsList) {
+	for _, scene := range scenes.Data {
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		scene.Group.addGroupTag(tags, rooms, zones)
+		tags["huebridge_scene"] = scene.Metadata.Name
+		tags["huebridge_scene_type"] = "scene"
+		fields := make(map[string]interface{})
+		if scene.Status.Active != "inactive" {
+			fields["active"] = 1
+		} else {
+			fields["active"] = 0
+		}
+		fields["status"] = scene.Status.Active
+		a.AddCounter("huebridge_scene", fields, tags)
+	}
+}
+
+func (plugin *HueBridge) evalSmartScenes(a telegraf.Accumulator, bridgeUrl string, smartScenes *smartScenesStatus, rooms *roomsList, zones *roomsList) {
+	for _, smartScene := range smartScenes.Data {
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		smartScene.Group.addGroupTag(tags, rooms, zones)
+		tags["huebridge_scene"] = smartScene.Metadata.Name
+		tags["huebridge_scene_type"] = "smart_scene"
+		fields := make(map[string]interface{})
+		if smartScene.State == "active" {
+			fields["active"] = 1
+		} else {
+			fields["active"] = 0
+		}
+		fields["status"] = smartScene.State
+		a.AddCounter("huebridge_scene", fields, tags)
+	}
+}
+
+func (plugin *HueBridge) evalBehaviorInstances(a telegraf.Accumulator, bridgeUrl string, behaviorInstances *behaviorInstancesStatus, behaviorScripts *behaviorScriptsList) {
+	for _, behaviorInstance := range behaviorInstances.Data {
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		tags["huebridge_automation"] = behaviorInstance.Metadata.Name
+		fields := make(map[string]interface{})
+		behaviorScript := behaviorScripts.findBehaviorScriptData(behaviorInstance.ScriptId)
+		if behaviorScript != nil {
+			tags["huebridge_automation_script"] = behaviorScript.Metadata.Name
+			fields["script_category"] = behaviorScript.Metadata.Category
+		} else {
+			tags["huebridge_automation_script"] = undefinedScript
+		}
+		if behaviorInstance.Enabled {
+			fields["enabled"] = 1
+		} else {
+			fields["enabled"] = 0
+		}
+		fields["status"] = behaviorInstance.Status
+		fields["last_error"] = behaviorInstance.LastError
+		a.AddCounter("huebridge_automation", fields, tags)
+	}
+}
+
+func (plugin *HueBridge) evalPresence(a telegraf.Accumulator, bridgeUrl string, geolocations *geolocationsStatus, geofenceClients *geofenceClientsList) {
+	// the bridge does not report the home/away state of the geofence clients (is_at_home is write-only)
+	// nor the configured location, hence only the presence setup and the derived sun state is reported
+	for _, geolocation := range geolocations.Data {
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		fields := make(map[string]interface{})
+		if geolocation.IsConfigured {
+			fields["geolocation_configured"] = 1
+		} else {
+			fields["geolocation_configured"] = 0
+		}
+		fields["geofence_clients"] = len(geofenceClients.Data)
+		if geolocation.SunToday != nil {
+			fields["day_type"] = geolocation.SunToday.DayType
+			fields["sunset_time"] = geolocation.SunToday.SunsetTime
+		}
+		a.AddCounter("huebridge_presence", fields, tags)
+	}
+}
+
+func (plugin *HueBridge) evalEntertainmentConfigurations(a telegraf.Accumulator, bridgeUrl string, entertainmentConfigurations *entertainmentConfigurationsStatus, devices *devicesList) {
+	for _, entertainmentConfiguration := range entertainmentConfigurations.Data {
+		active := entertainmentConfiguration.Status == "active"
+		plugin.updateStreamingLights(bridgeUrl, entertainmentConfiguration.Id, entertainmentConfiguration.LightServices, active)
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		tags["huebridge_entertainment"] = entertainmentConfiguration.Metadata.Name
+		fields := make(map[string]interface{})
+		if active {
+			fields["active"] = 1
+		} else {
+			fields["active"] = 0
+		}
+		fields["status"] = entertainmentConfiguration.Status
+		fields["configuration_type"] = entertainmentConfiguration.ConfigurationType
+		fields["channels"] = len(entertainmentConfiguration.Channels)
+		fields["lights"] = len(entertainmentConfiguration.LightServices)
+		if entertainmentConfiguration.ActiveStreamer != nil {
+			// streaming devices (e.g. a Sync box) are resolved to their name, streaming applications
+			// (auth_v1) are only known by their id
+			fields["streamer_id"] = entertainmentConfiguration.ActiveStreamer.Rid
+			fields["streamer_type"] = entertainmentConfiguration.ActiveStreamer.Rtype
+			if entertainmentConfiguration.ActiveStreamer.Rtype == "device" {
+				fields["streamer"] = entertainmentConfiguration.ActiveStreamer.getDeviceName(devices)
+			}
+		}
+		a.AddCounter("huebridge_entertainment", fields, tags)
+	}
+}
+
+func (plugin *HueBridge) updateStreamingLights(bridgeUrl string, entertainmentConfigurationId string, lightServices []resourceLink, active bool) {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.streamingLights == nil {
+		plugin.streamingLights = make(map[string]map[string]string)
+	}
+	streamingLights := plugin.streamingLights[bridgeUrl]
+	if streamingLights == nil {
+		streamingLights = make(map[string]string)
+		plugin.streamingLights[bridgeUrl] = streamingLights
+	}
+	for _, lightService := range lightServices {
+		if active {
+			streamingLights[lightService.Rid] = entertainmentConfigurationId
+		} else if streamingLights[lightService.Rid] == entertainmentConfigurationId {
+			delete(streamingLights, lightService.Rid)
+		}
+	}
+}
+
+func (plugin *HueBridge) isLightStreaming(bridgeUrl string, lightId string) bool {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	return plugin.streamingLights[bridgeUrl][lightId] != ""
+}
+
+func (plugin *HueBridge) evalButtons(a telegraf.Accumulator, bridgeUrl string, buttons *buttonsStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	for _, button := range buttons.Data {
+		buttonDeviceName, buttonRoomName := button.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		tags["huebridge_room"] = buttonRoomName
+		tags["huebridge_device"] = buttonDeviceName
+		plugin.addDeviceTags(tags, &button.Owner, devices)
+		tags["huebridge_control_id"] = strconv.Itoa(button.Metadata.ControlId)
+		fields := make(map[string]interface{})
+		lastEvent := button.Button.LastEvent
+		updated := ""
+		if button.Button.ButtonReport != nil {
+			lastEvent = button.Button.ButtonReport.Event
+			updated = button.Button.ButtonReport.Updated
+			fields["updated"] = updated
+		}
+		if lastEvent != "" {
+			fields["last_event"] = lastEvent
+		}
+		fields["presses"] = plugin.countReport(button.Id, updated)
+		if t, emit := plugin.reportTime(button.Id, updated); emit {
+			plugin.addResourceMetric(a, "huebridge_button", fields, tags, button.Owner.getZoneNames(button.Id, devices, zones), t...)
+		}
+	}
+}
+
+func (plugin *HueBridge) evalRelativeRotaries(a telegraf.Accumulator, bridgeUrl string, relativeRotaries *relativeRotariesStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	for _, relativeRotary := range relativeRotaries.Data {
+		relativeRotaryDeviceName, relativeRotaryRoomName := relativeRotary.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		tags["huebridge_room"] = relativeRotaryRoomName
+		tags["huebridge_device"] = relativeRotaryDeviceName
+		plugin.addDeviceTags(tags, &relativeRotary.Owner, devices)
+		fields := make(map[string]interface{})
+		lastEvent := relativeRotary.RelativeRotary.LastEvent
+		updated := ""
+		if relativeRotary.RelativeRotary.RotaryReport != nil {
+			lastEvent = &relativeRotary.RelativeRotary.RotaryReport.relativeRotaryEvent
+			updated = relativeRotary.RelativeRotary.RotaryReport.Updated
+			fields["updated"] = updated
+		}
+		if lastEvent != nil {
+			fields["last_event"] = lastEvent.Action
+			fields["rotation_direction"] = lastEvent.Rotation.Direction
+			fields["rotation_steps"] = lastEvent.Rotation.Steps
+			fields["rotation_duration"] = lastEvent.Rotation.Duration
+		}
+		fields["rotations"] = plugin.countReport(relativeRotary.Id, updated)
+		if t, emit := plugin.reportTime(relativeRotary.Id, updated); emit {
+			plugin.addResourceMetric(a, "huebridge_rotary", fields, tags, relativeRotary.Owner.getZoneNames(relativeRotary.Id, devices, zones), t...)
+		}
+	}
+}
+
+func (plugin *HueBridge) evalContacts(a telegraf.Accumulator, bridgeUrl string, contacts *contactsStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	for _, contact := range contacts.Data {
+		if contact.Enabled && contact.ContactReport != nil {
+			contactDeviceName, contactRoomName := contact.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
+			tags := make(map[string]string)
+			tags["huebridge_url"] = bridgeUrl
+			tags["huebridge_room"] = contactRoomName
+			tags["huebridge_device"] = contactDeviceName
+			plugin.addDeviceTags(tags, &contact.Owner, devices)
+			fields := make(map[string]interface{})
+			if contact.ContactReport.State == "contact" {
+				fields["contact"] = 1
+			} else {
+				fields["contact"] = 0
+			}
+			fields["state"] = contact.ContactReport.State
+			fields["changed"] = contact.ContactReport.Changed
+			if t, emit := plugin.reportTime(contact.Id, contact.ContactReport.Changed); emit {
+				plugin.addResourceMetric(a, "huebridge_contact", fields, tags, contact.Owner.getZoneNames(contact.Id, devices, zones), t...)
+			}
+		}
+	}
+}
+
+func (plugin *HueBridge) evalTampers(a telegraf.Accumulator, bridgeUrl string, tampers *tampersStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	for _, tamper := range tampers.Data {
+		tamperDeviceName, tamperRoomName := tamper.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
+		for _, tamperReport := range tamper.TamperReports {
+			tags := make(map[string]string)
+			tags["huebridge_url"] = bridgeUrl
+			tags["huebridge_room"] = tamperRoomName
+			tags["huebridge_device"] = tamperDeviceName
+			plugin.addDeviceTags(tags, &tamper.Owner, devices)
+			tags["huebridge_tamper_source"] = tamperReport.Source
+			fields := make(map[string]interface{})
+			if tamperReport.State == "tampered" {
+				fields["tampered"] = 1
+			} else {
+				fields["tampered"] = 0
+			}
+			fields["state"] = tamperReport.State
+			fields["changed"] = tamperReport.Changed
+			plugin.addResourceMetric(a, "huebridge_tamper", fields, tags, tamper.Owner.getZoneNames(tamper.Id, devices, zones))
+		}
+	}
+}
+
+func (plugin *HueBridge) evalZigbeeConnectivities(a telegraf.Accumulator, bridgeUrl string, zigbeeConnectivities *zigbeeConnectivitiesStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	for _, zigbeeConnectivity := range zigbeeConnectivities.Data {
+		plugin.updateDeviceReachability(zigbeeConnectivity.Owner.Rid, zigbeeConnectivity.Status)
+		zigbeeConnectivityDeviceName, zigbeeConnectivityRoomName := zigbeeConnectivity.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		tags["huebridge_room"] = zigbeeConnectivityRoomName
+		tags["huebridge_device"] = zigbeeConnectivityDeviceName
+		plugin.addDeviceTags(tags, &zigbeeConnectivity.Owner, devices)
+		tags["huebridge_connectivity_type"] = "zigbee"
+		fields := make(map[string]interface{})
+		if zigbeeConnectivity.Status == connectivityStatusConnected {
+			fields["connected"] = 1
+		} else {
+			fields["connected"] = 0
+		}
+		fields["status"] = zigbeeConnectivity.Status
+		fields["mac_address"] = zigbeeConnectivity.MacAddress
+		plugin.addResourceMetric(a, "huebridge_connectivity", fields, tags, zigbeeConnectivity.Owner.getZoneNames(zigbeeConnectivity.Id, devices, zones))
+	}
+}
+
+func (plugin *HueBridge) evalZgpConnectivities(a telegraf.Accumulator, bridgeUrl string, zgpConnectivities *zgpConnectivitiesStatus, devices *devicesList, rooms *roomsList, zones *roomsList) {
+	for _, zgpConnectivity := range zgpConnectivities.Data {
+		plugin.updateDeviceReachability(zgpConnectivity.Owner.Rid, zgpConnectivity.Status)
+		zgpConnectivityDeviceName, zgpConnectivityRoomName := zgpConnectivity.Owner.getDeviceAndRoomName(devices, rooms, plugin.roomAssignments(bridgeUrl))
+		tags := make(map[string]string)
+		tags["huebridge_url"] = bridgeUrl
+		tags["huebridge_room"] = zgpConnectivityRoomName
+		tags["huebridge_device"] = zgpConnectivityDeviceName
+		plugin.addDeviceTags(tags, &zgpConnectivity.Owner, devices)
+		tags["huebridge_connectivity_type"] = "zgp"
+		fields := make(map[string]interface{})
+		if zgpConnectivity.Status == connectivityStatusConnected {
+			fields["connected"] = 1
+		} else {
+			fields["connected"] = 0
+		}
+		fields["status"] = zgpConnectivity.Status
+		fields["source_id"] = zgpConnectivity.SourceId
+		plugin.addResourceMetric(a, "huebridge_connectivity", fields, tags, zgpConnectivity.Owner.getZoneNames(zgpConnectivity.Id, devices, zones))
+	}
+}
+
+const connectivityStatusConnected = "connected"
+
+func (plugin *HueBridge) updateDeviceReachability(deviceId string, status string) {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.unreachableDevices == nil {
+		plugin.unreachableDevices = make(map[string]bool)
+	}
+	if status == connectivityStatusConnected {
+		delete(plugin.unreachableDevices, deviceId)
+	} else {
+		plugin.unreachableDevices[deviceId] = true
+	}
+}
+
+func (plugin *HueBridge) isDeviceReachable(deviceId string) bool {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	return !plugin.unreachableDevices[deviceId]
+}
+
+func (plugin *HueBridge) addResourceMetric(a telegraf.Accumulator, measurement string, fields map[string]interface{}, tags map[string]string, zoneNames []string, t ...time.Time) {
+	switch plugin.ZoneMembership {
+	case zoneMembershipTag:
+		if len(zoneNames) > 0 {
+			tags["huebridge_zone"] = strings.Join(zoneNames, ",")
+		}
+		a.AddCounter(measurement, fields, tags, t...)
+	case zoneMembershipSeries:
+		a.AddCounter(measurement, fields, tags, t...)
+		for _, zoneName := range zoneNames {
+			zoneTags := make(map[string]string)
+			for key, value := range tags {
+				zoneTags[key] = value
+			}
+			zoneTags["huebridge_zone"] = zoneName
+			a.AddCounter(measurement, fields, zoneTags, t...)
+		}
+	default:
+		a.AddCounter(measurement, fields, tags, t...)
+	}
+}
+
+type requestStats struct {
+	requests int
+	errors   int
+	latency  time.Duration
+}
+
+func (plugin *HueBridge) recordRequest(bridgeUrl string, latency time.Duration, err error) {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.requestStats == nil {
+		plugin.requestStats = make(map[string]*requestStats)
+	}
+	stats := plugin.requestStats[bridgeUrl]
+	if stats == nil {
+		stats = &requestStats{}
+		plugin.requestStats[bridgeUrl] = stats
+	}
+	stats.requests++
+	if err != nil {
+		stats.errors++
+	}
+	stats.latency += latency
+}
+
+func (plugin *HueBridge) resetRequestStats(bridgeUrl string) requestStats {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	stats := plugin.requestStats[bridgeUrl]
+	if stats == nil {
+		return requestStats{}
+	}
+	delete(plugin.requestStats, bridgeUrl)
+	return *stats
+}
+
+// reportTime determines the metric timestamp for a resource report. If report timestamps are enabled,
+// the report's change time is returned and false in case this change has already been emitted.
+func (plugin *HueBridge) reportTime(resourceId string, changed string) ([]time.Time, bool) {
+	if !plugin.ReportTimestamps {
+		return nil, true
+	}
+	changedTime, err := time.Parse(time.RFC3339Nano, changed)
+	if err != nil {
+		// no (valid) report available, fall back to the gather time
+		return nil, true
+	}
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.reportedChanges == nil {
+		plugin.reportedChanges = make(map[string]string)
+	}
+	if plugin.reportedChanges[resourceId] == changed {
+		return nil, false
+	}
+	plugin.reportedChanges[resourceId] = changed
+	return []time.Time{changedTime}, true
+}
+
+type reportCounter struct {
+	updated string
+	count   int
+}
+
+// countReport counts the reports of a resource by tracking the changes of it's report update timestamp
+func (plugin *HueBridge) countReport(resourceId string, updated string) int {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.reportCounters == nil {
+		plugin.reportCounters = make(map[string]*reportCounter)
+	}
+	counter := plugin.reportCounters[resourceId]
+	if counter == nil {
+		counter = &reportCounter{updated: updated}
+		plugin.reportCounters[resourceId] = counter
+	} else if counter.updated != updated {
+		counter.updated = updated
+		counter.count++
+	}
+	return counter.count
+}
+
+type bridgesStatus struct {
+	Data []bridgeData `json:"data"`
+}
+
+type bridgeData struct {
+	BridgeId string       `json:"bridge_id"`
+	Owner    resourceLink `json:"owner"`
+}
+
+type bridgeConfig struct {
+	Name       string `json:"name"`
+	ModelId    string `json:"modelid"`
+	SwVersion  string `json:"swversion"`
+	ApiVersion string `json:"apiversion"`
+	Mac        string `json:"mac"`
+}
+
+type lightsStatus struct {
+	Data []lightData `json:"data"`
+}
+
+type lightData struct {
+	Id               string                 `json:"id"`
+	On               lightOn                `json:"on"`
+	Dimming          *lightDimming          `json:"dimming"`
+	ColorTemperature *lightColorTemperature `json:"color_temperature"`
+	Color            *lightColor            `json:"color"`
+	Dynamics         *lightStatus           `json:"dynamics"`
+	Effects          *lightStatus           `json:"effects"`
+	Mode             string                 `json:"mode"`
+	Owner            resourceLink           `json:"owner"`
+}
+
+type lightOn struct {
+	On bool `json:"on"`
+}
+
+type lightDimming struct {
+	Brightness float64 `json:"brightness"`
+}
+
+type lightColorTemperature struct {
+	Mirek      int  `json:"mirek"`
+	MirekValid bool `json:"mirek_valid"`
+}
+
+type lightColor struct {
+	XY        lightColorXY `json:"xy"`
+	GamutType string       `json:"gamut_type"`
+}
+
+type lightColorXY struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type lightStatus struct {
+	Status string `json:"status"`
+}
+
+// xyToRGBHex converts a CIE xy color point and brightness (0-100) into an approximate sRGB hex value
+func xyToRGBHex(x float64, y float64, brightness float64) string {
+	if y <= 0.0 {
+		return "#000000"
+	}
+	z := 1.0 - x - y
+	cY := brightness / 100.0
+	cX := (cY / y) * x
+	cZ := (cY / y) * z
+	rgb := []float64{
+		cX*3.2406 - cY*1.5372 - cZ*0.4986,
+		-cX*0.9689 + cY*1.8758 + cZ*0.0415,
+		cX*0.0557 - cY*0.2040 + cZ*1.0570,
+	}
+	maxValue := 1.0
+	for i, value := range rgb {
+		if value <= 0.0031308 {
+			value = 12.92 * value
+		} else {
+			value = 1.055*math.Pow(value, 1.0/2.4) - 0.055
+		}
+		rgb[i] = math.Max(value, 0.0)
+		maxValue = math.Max(maxValue, rgb[i])
+	}
+	return fmt.Sprintf("#%02x%02x%02x", int(math.Round(rgb[0]/maxValue*255.0)), int(math.Round(rgb[1]/maxValue*255.0)), int(math.Round(rgb[2]/maxValue*255.0)))
+}
+
+type groupedLightsStatus struct {
+	Data []groupedLightData `json:"data"`
+}
+
+type groupedLightData struct {
+	On      lightOn       `json:"on"`
+	Dimming *lightDimming `json:"dimming"`
+	Owner   resourceLink  `json:"owner"`
+}
+
+type temperaturesStatus struct {
+	Data []temperatureData `json:"data"`
+}
+
+type temperatureData struct {
+	Id          string                 `json:"id"`
+	Enabled     bool                   `json:"enabled"`
+	Temperature temperatureTemperature `json:"temperature"`
+	Owner       resourceLink           `json:"owner"`
+}
+
+type temperatureTemperature struct {
+	Temperature       float32            `json:"temperature"`
+	TemperatureValid  bool               `json:"temperature_valid"`
+	TemperatureReport *temperatureReport `json:"temperature_report"`
+}
+
+type temperatureReport struct {
+	Changed     string  `json:"changed"`
+	Temperature float32 `json:"temperature"`
+}
+
+type lightLevelsStatus struct {
+	Data []lightLevelData `json:"data"`
+}
+
+type lightLevelData struct {
+	Id      string          `json:"id"`
+	Enabled bool            `json:"enabled"`
+	Light   lightLevelLight `json:"light"`
+	Owner   resourceLink    `json:"owner"`
+}
+
+type lightLevelLight struct {
+	LightLevel       float32           `json:"light_level"`
+	LightLevelValid  bool              `json:"light_level_valid"`
+	LightLevelReport *lightLevelReport `json:"light_level_report"`
+}
+
+type groupedLightLevelsStatus struct {
+	Data []groupedLightLevelData `json:"data"`
+}
+
+type groupedLightLevelData struct {
+	Id      string                 `json:"id"`
+	Enabled bool                   `json:"enabled"`
+	Light   groupedLightLevelLight `json:"light"`
+	Owner   resourceLink           `json:"owner"`
+}
+
+type groupedLightLevelLight struct {
+	LightLevelReport *lightLevelReport `json:"light_level_report"`
+}
+
+type lightLevelReport struct {
+	Changed    string  `json:"changed"`
+	LightLevel float32 `json:"light_level"`
+}
+
+type motionsStatus struct {
+	Data []motionData `json:"data"`
+}
+
+type motionData struct {
+	Id          string             `json:"id"`
+	Enabled     bool               `json:"enabled"`
+	Motion      motionMotion       `json:"motion"`
+	Sensitivity *motionSensitivity `json:"sensitivity"`
+	Owner       resourceLink       `json:"owner"`
+}
+
+func (ms *motionsStatus) hasMotionAreaOwner() bool {
+	for _, motion := range ms.Data {
+		if motion.Owner.Rtype == "motion_area_configuration" {
+			return true
+		}
+	}
+	return false
+}
+
+type motionAreaConfigurationsList struct {
+	Data []motionAreaConfigurationData `json:"data"`
+}
+
+func (mas *motionAreaConfigurationsList) getMotionAreaName(motionAreaId string) string {
+	if mas != nil {
+		for _, motionArea := range mas.Data {
+			if motionArea.Id == motionAreaId {
+				return motionArea.Metadata.Name
+			}
+		}
+	}
+	return undefinedMotionArea
+}
+
+type motionAreaConfigurationData struct {
+	Id       string           `json:"id"`
+	Metadata resourceMetadata `json:"metadata"`
+}
+
+type motionMotion struct {
+	Motion       bool          `json:"motion"`
+	MotionValid  bool          `json:"motion_valid"`
+	MotionReport *motionReport `json:"motion_report"`
+}
+
+type motionSensitivity struct {
+	Sensitivity    int `json:"sensitivity"`
+	SensitivityMax int `json:"sensitivity_max"`
+}
+
+type groupedMotionsStatus struct {
+	Data []groupedMotionData `json:"data"`
+}
+
+type groupedMotionData struct {
+	Id      string              `json:"id"`
+	Enabled bool                `json:"enabled"`
+	Motion  groupedMotionMotion `json:"motion"`
+	Owner   resourceLink        `json:"owner"`
+}
+
+type groupedMotionMotion struct {
+	MotionReport *motionReport `json:"motion_report"`
+}
+
+type motionReport struct {
+	Changed string `json:"changed"`
+	Motion  bool   `json:"motion"`
+}
+
+type devicePowersStatus struct {
+	Data []devicePowerData `json:"data"`
+}
+
+type devicePowerData struct {
+	PowerState devicePowerState `json:"power_state"`
+	Owner      resourceLink     `json:"owner"`
+}
+
+type devicePowerState struct {
+	BatteryState string `json:"battery_state"`
+	BatteryLevel int    `json:"battery_level"`
+}
+
+type deviceSoftwareUpdatesStatus struct {
+	Data []deviceSoftwareUpdateData `json:"data"`
+}
+
+type deviceSoftwareUpdateData struct {
+	Id       string       `json:"id"`
+	State    string       `json:"state"`
+	Problems []string     `json:"problems"`
+	Owner    resourceLink `json:"owner"`
+}
+
+type scenesStatus struct {
+	Data []sceneData `json:"data"`
+}
+
+type sceneData struct {
+	Metadata resourceMetadata `json:"metadata"`
+	Group    resourceLink     `json:"group"`
+	Status   sceneStatus      `json:"status"`
+}
+
+type sceneStatus struct {
+	Active string `json:"active"`
+}
+
+type smartScenesStatus struct {
+	Data []smartSceneData `json:"data"`
+}
+
+type smartSceneData struct {
+	Metadata resourceMetadata `json:"metadata"`
+	Group    resourceLink     `json:"group"`
+	State    string           `json:"state"`
+}
+
+type behaviorInstancesStatus struct {
+	Data []behaviorInstanceData `json:"data"`
+}
+
+type behaviorInstanceData struct {
+	ScriptId  string           `json:"script_id"`
+	Enabled   bool             `json:"enabled"`
+	Status    string           `json:"status"`
+	LastError string           `json:"last_error"`
+	Metadata  resourceMetadata `json:"metadata"`
+}
+
+type behaviorScriptsList struct {
+	Data []behaviorScriptData `json:"data"`
+}
+
+func (bs *behaviorScriptsList) findBehaviorScriptData(scriptId string) *behaviorScriptData {
+	for _, behaviorScript := range bs.Data {
+		if behaviorScript.Id == scriptId {
+			return &behaviorScript
+		}
+	}
+	return nil
+}
+
+type behaviorScriptData struct {
+	Id       string                 `json:"id"`
+	Metadata behaviorScriptMetadata `json:"metadata"`
+}
+
+type behaviorScriptMetadata struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+type geolocationsStatus struct {
+	Data []geolocationData `json:"data"`
+}
+
+type geolocationData struct {
+	IsConfigured bool                `json:"is_configured"`
+	SunToday     *geolocationSunData `json:"sun_today"`
+}
+
+type geolocationSunData struct {
+	SunsetTime string `json:"sunset_time"`
+	DayType    string `json:"day_type"`
+}
+
+type geofenceClientsList struct {
+	Data []geofenceClientData `json:"data"`
+}
+
+type geofenceClientData struct {
+	Id string `json:"id"`
+}
+
+type entertainmentConfigurationsStatus struct {
+	Data []entertainmentConfigurationData `json:"data"`
+}
+
+type entertainmentConfigurationData struct {
+	Id                string            `json:"id"`
+	Metadata          resourceMetadata  `json:"metadata"`
+	ConfigurationType string            `json:"configuration_type"`
+	Status            string            `json:"status"`
+	ActiveStreamer    *resourceLink     `json:"active_streamer"`
+	Channels          []json.RawMessage `json:"channels"`
+	LightServices     []resourceLink    `json:"light_services"`
+}
+
+type buttonsStatus struct {
+	Data []buttonData `json:"data"`
+}
+
+type buttonData struct {
+	Id       string         `json:"id"`
+	Metadata buttonMetadata `json:"metadata"`
+	Button   buttonButton   `json:"button"`
+	Owner    resourceLink   `json:"owner"`
+}
+
+type buttonMetadata struct {
+	ControlId int `json:"control_id"`
+}
+
+type buttonButton struct {
+	LastEvent    string        `json:"last_event"`
+	ButtonReport *buttonReport `json:"button_report"`
+}
+
+type buttonReport struct {
+	Updated string `json:"updated"`
+	Event   string `json:"event"`
+}
+
+type relativeRotariesStatus struct {
+	Data []relativeRotaryData `json:"data"`
+}
+
+type relativeRotaryData struct {
+	Id             string                       `json:"id"`
+	RelativeRotary relativeRotaryRelativeRotary `json:"relative_rotary"`
+	Owner          resourceLink                 `json:"owner"`
 }
 
-type lightData struct {
-	On    lightOn      `json:"on"`
-	Owner resourceLink `json:"owner"`
+type relativeRotaryRelativeRotary struct {
+	LastEvent    *relativeRotaryEvent  `json:"last_event"`
+	RotaryReport *relativeRotaryReport `json:"rotary_report"`
 }
 
-type lightOn struct {
-	On bool `json:"on"`
+type relativeRotaryEvent struct {
+	Action   string                 `json:"action"`
+	Rotation relativeRotaryRotation `json:"rotation"`
 }
 
-type temperaturesStatus struct {
-	Data []temperatureData `json:"data"`
+type relativeRotaryReport struct {
+	relativeRotaryEvent
+	Updated string `json:"updated"`
 }
 
-type temperatureData struct {
-	Enabled     bool                   `json:"enabled"`
-	Temperature temperatureTemperature `json:"temperature"`
-	Owner       resourceLink           `json:"owner"`
+type relativeRotaryRotation struct {
+	Direction string `json:"direction"`
+	Steps     int    `json:"steps"`
+	Duration  int    `json:"duration"`
 }
 
-type temperatureTemperature struct {
-	Temperature      float32 `json:"temperature"`
-	TemperatureValid bool    `json:"temperature_valid"`
+type contactsStatus struct {
+	Data []contactData `json:"data"`
 }
 
-type lightLevelsStatus struct {
-	Data []lightLevelData `json:"data"`
+type contactData struct {
+	Id            string         `json:"id"`
+	Enabled       bool           `json:"enabled"`
+	ContactReport *contactReport `json:"contact_report"`
+	Owner         resourceLink   `json:"owner"`
 }
 
-type lightLevelData struct {
-	Enabled bool            `json:"enabled"`
-	Light   lightLevelLight `json:"light"`
-	Owner   resourceLink    `json:"owner"`
+type contactReport struct {
+	Changed string `json:"changed"`
+	State   string `json:"state"`
 }
 
-type lightLevelLight struct {
-	LightLevel      float32 `json:"light_level"`
-	LightLevelValid bool    `json:"light_level_valid"`
+type tampersStatus struct {
+	Data []tamperData `json:"data"`
 }
 
-type motionsStatus struct {
-	Data []motionData `json:"data"`
+type tamperData struct {
+	Id            string         `json:"id"`
+	TamperReports []tamperReport `json:"tamper_reports"`
+	Owner         resourceLink   `json:"owner"`
 }
 
-type motionData struct {
-	Enabled bool         `json:"enabled"`
-	Motion  motionMotion `json:"motion"`
-	Owner   resourceLink `json:"owner"`
+type tamperReport struct {
+	Changed string `json:"changed"`
+	Source  string `json:"source"`
+	State   string `json:"state"`
 }
 
-type motionMotion struct {
-	Motion      bool `json:"motion"`
-	MotionValid bool `json:"motion_valid"`
+type zigbeeConnectivitiesStatus struct {
+	Data []zigbeeConnectivityData `json:"data"`
 }
 
-type devicePowersStatus struct {
-	Data []devicePowerData `json:"data"`
+type zigbeeConnectivityData struct {
+	Id         string                     `json:"id"`
+	Status     string                     `json:"status"`
+	MacAddress string                     `json:"mac_address"`
+	Channel    *zigbeeConnectivityChannel `json:"channel"`
+	Owner      resourceLink               `json:"owner"`
 }
 
-type devicePowerData struct {
-	PowerState devicePowerState `json:"power_state"`
-	Owner      resourceLink     `json:"owner"`
+type zigbeeConnectivityChannel struct {
+	Status string `json:"status"`
+	Value  string `json:"value"`
 }
 
-type devicePowerState struct {
-	BatteryState string `json:"battery_state"`
-	BatteryLevel int    `json:"battery_level"`
+type zgpConnectivitiesStatus struct {
+	Data []zgpConnectivityData `json:"data"`
+}
+
+type zgpConnectivityData struct {
+	Id       string       `json:"id"`
+	Status   string       `json:"status"`
+	SourceId string       `json:"source_id"`
+	Owner    resourceLink `json:"owner"`
 }
 
 type devicesList struct {
@@ -286,14 +1940,49 @@ func (ds *devicesList) findDeviceData(deviceId string) *deviceData {
 }
 
 type deviceData struct {
-	Id       string           `json:"id"`
-	Metadata resourceMetadata `json:"metadata"`
+	Id          string            `json:"id"`
+	ProductData deviceProductData `json:"product_data"`
+	Metadata    resourceMetadata  `json:"metadata"`
+	Services    []resourceLink    `json:"services"`
+}
+
+type deviceProductData struct {
+	ModelId          string `json:"model_id"`
+	ManufacturerName string `json:"manufacturer_name"`
+	ProductName      string `json:"product_name"`
+	ProductArchetype string `json:"product_archetype"`
+	Certified        bool   `json:"certified"`
+	SoftwareVersion  string `json:"software_version"`
+}
+
+func (d *deviceData) getDeviceTag(deviceTag string) string {
+	switch deviceTag {
+	case "model":
+		return d.ProductData.ModelId
+	case "archetype":
+		return d.ProductData.ProductArchetype
+	case "manufacturer":
+		return d.ProductData.ManufacturerName
+	case "product":
+		return d.ProductData.ProductName
+	}
+	return ""
 }
 
+// roomsList is used for rooms as well as for zones, as both share the same structure
 type roomsList struct {
 	Data []roomData `json:"data"`
 }
 
+func (rs *roomsList) findRoomData(roomId string) *roomData {
+	for _, room := range rs.Data {
+		if room.Id == roomId {
+			return &room
+		}
+	}
+	return nil
+}
+
 func (rs *roomsList) findDeviceRoomData(childDeviceId string) *roomData {
 	for _, room := range rs.Data {
 		for _, child := range room.Children {
@@ -311,6 +2000,15 @@ type roomData struct {
 	Children []resourceLink   `json:"children"`
 }
 
+func (r *roomData) containsLight(light *lightData) bool {
+	for _, child := range r.Children {
+		if (child.Rtype == "device" && child.Rid == light.Owner.Rid) || (child.Rtype == "light" && child.Rid == light.Id) {
+			return true
+		}
+	}
+	return false
+}
+
 type resourceMetadata struct {
 	Archetype string `json:"archetype"`
 	Name      string `json:"name"`
@@ -323,6 +2021,39 @@ type resourceLink struct {
 
 const undefinedDevice = "<undefined>"
 const unassignedDevice = "<unassigned>"
+const undefinedScript = "<undefined>"
+const undefinedMotionArea = "<undefined>"
+
+func (plugin *HueBridge) addDeviceTags(tags map[string]string, rl *resourceLink, devices *devicesList) {
+	if len(plugin.DeviceTags) == 0 || rl.Rtype != "device" {
+		return
+	}
+	device := devices.findDeviceData(rl.Rid)
+	if device == nil {
+		return
+	}
+	for _, deviceTag := range plugin.DeviceTags {
+		tags[deviceTagNames[deviceTag]] = device.getDeviceTag(deviceTag)
+	}
+}
+
+// addGroupTag adds the room or zone tag for a room or zone link and returns the linked group
+func (rl *resourceLink) addGroupTag(tags map[string]string, rooms *roomsList, zones *roomsList) *roomData {
+	var group *roomData
+	switch rl.Rtype {
+	case "room":
+		group = rooms.findRoomData(rl.Rid)
+		if group != nil {
+			tags["huebridge_room"] = group.Metadata.Name
+		}
+	case "zone":
+		group = zones.findRoomData(rl.Rid)
+		if group != nil {
+			tags["huebridge_zone"] = group.Metadata.Name
+		}
+	}
+	return group
+}
 
 func (rl *resourceLink) getDeviceName(devices *devicesList) string {
 	deviceName := undefinedDevice
@@ -335,6 +2066,34 @@ func (rl *resourceLink) getDeviceName(devices *devicesList) string {
 	return deviceName
 }
 
+// getZoneNames determines the zones containing the given resource. As zones are made up of services
+// (e.g. lights) rather than devices, the owning device's services are taken into account as well.
+func (rl *resourceLink) getZoneNames(resourceId string, devices *devicesList, zones *roomsList) []string {
+	zoneNames := make([]string, 0)
+	if zones == nil {
+		return zoneNames
+	}
+	resourceIds := []string{resourceId}
+	if rl.Rtype == "device" {
+		resourceIds = append(resourceIds, rl.Rid)
+		device := devices.findDeviceData(rl.Rid)
+		if device != nil {
+			for _, service := range device.Services {
+				resourceIds = append(resourceIds, service.Rid)
+			}
+		}
+	}
+	for _, zone := range zones.Data {
+		for _, child := range zone.Children {
+			if slices.Contains(resourceIds, child.Rid) {
+				zoneNames = append(zoneNames, zone.Metadata.Name)
+				break
+			}
+		}
+	}
+	return zoneNames
+}
+
 func (rl *resourceLink) getDeviceAndRoomName(devices *devicesList, rooms *roomsList, roomAssignments [][]string) (string, string) {
 	deviceName := undefinedDevice
 	roomName := unassignedDevice
@@ -360,6 +2119,78 @@ func (rl *resourceLink) getDeviceAndRoomName(devices *devicesList, rooms *roomsL
 	return deviceName, roomName
 }
 
+func (plugin *HueBridge) fetchBridge(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*bridgeData, error) {
+	var bridgesStatus bridgesStatus
+
+	jsonUrl, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/bridge", &bridgesStatus)
+	if err != nil {
+		return nil, err
+	}
+	if len(bridgesStatus.Data) != 1 {
+		return nil, fmt.Errorf("unexpected bridge data received from %s", jsonUrl)
+	}
+	return &bridgesStatus.Data[0], nil
+}
+
+type cachedBridgeConfig struct {
+	config  *bridgeConfig
+	fetched time.Time
+}
+
+// fetchBridgeConfig fetches the bridge config. As the config rarely changes, it is cached and only
+// re-fetched after the device info interval has elapsed or a request to the bridge has failed.
+func (plugin *HueBridge) fetchBridgeConfig(a telegraf.Accumulator, bridgeUrl string) (*bridgeConfig, error) {
+	plugin.stateLock.Lock()
+	cached := plugin.bridgeConfigs[bridgeUrl]
+	plugin.stateLock.Unlock()
+	if cached != nil && time.Since(cached.fetched) < time.Duration(plugin.DeviceInfoInterval)*time.Second {
+		return cached.config, nil
+	}
+
+	var bridgeConfig bridgeConfig
+
+	// the bridge config is accessible without application key
+	_, err := plugin.fetchJSON(bridgeUrl, "", "/api/0/config", &bridgeConfig)
+	if err != nil {
+		return nil, err
+	}
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.bridgeConfigs == nil {
+		plugin.bridgeConfigs = make(map[string]*cachedBridgeConfig)
+	}
+	plugin.bridgeConfigs[bridgeUrl] = &cachedBridgeConfig{config: &bridgeConfig, fetched: time.Now()}
+	return &bridgeConfig, nil
+}
+
+func (plugin *HueBridge) forgetBridgeConfig(bridgeUrl string) {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	delete(plugin.bridgeConfigs, bridgeUrl)
+}
+
+func (plugin *HueBridge) fetchBridgeZigbeeConnectivity(a telegraf.Accumulator, bridgeUrl string, applicationKey string, bridge *bridgeData, devices *devicesList) (*zigbeeConnectivityData, error) {
+	bridgeDevice := devices.findDeviceData(bridge.Owner.Rid)
+	if bridgeDevice == nil {
+		return nil, nil
+	}
+	for _, service := range bridgeDevice.Services {
+		if service.Rtype == "zigbee_connectivity" {
+			var zigbeeConnectivitiesStatus zigbeeConnectivitiesStatus
+
+			jsonUrl, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/zigbee_connectivity/"+service.Rid, &zigbeeConnectivitiesStatus)
+			if err != nil {
+				return nil, err
+			}
+			if len(zigbeeConnectivitiesStatus.Data) != 1 {
+				return nil, fmt.Errorf("unexpected zigbee connectivity data received from %s", jsonUrl)
+			}
+			return &zigbeeConnectivitiesStatus.Data[0], nil
+		}
+	}
+	return nil, nil
+}
+
 func (plugin *HueBridge) fetchLights(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*lightsStatus, error) {
 	var lightsStatus lightsStatus
 
@@ -370,6 +2201,16 @@ func (plugin *HueBridge) fetchLights(a telegraf.Accumulator, bridgeUrl string, a
 	return &lightsStatus, nil
 }
 
+func (plugin *HueBridge) fetchGroupedLights(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*groupedLightsStatus, error) {
+	var groupedLightsStatus groupedLightsStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/grouped_light", &groupedLightsStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &groupedLightsStatus, nil
+}
+
 func (plugin *HueBridge) fetchTemperatures(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*temperaturesStatus, error) {
 	var temperaturesStatus temperaturesStatus
 
@@ -390,16 +2231,46 @@ func (plugin *HueBridge) fetchLightLevels(a telegraf.Accumulator, bridgeUrl stri
 	return &lightLevelsStatus, nil
 }
 
-func (plugin *HueBridge) fetchMotions(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*motionsStatus, error) {
+func (plugin *HueBridge) fetchMotions(a telegraf.Accumulator, bridgeUrl string, applicationKey string, motionType string) (*motionsStatus, error) {
 	var motionsStatus motionsStatus
 
-	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/motion", &motionsStatus)
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/"+motionType, &motionsStatus)
 	if err != nil {
 		return nil, err
 	}
 	return &motionsStatus, nil
 }
 
+func (plugin *HueBridge) fetchMotionAreaConfigurations(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*motionAreaConfigurationsList, error) {
+	var motionAreaConfigurationsList motionAreaConfigurationsList
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/motion_area_configuration", &motionAreaConfigurationsList)
+	if err != nil {
+		return nil, err
+	}
+	return &motionAreaConfigurationsList, nil
+}
+
+func (plugin *HueBridge) fetchGroupedMotions(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*groupedMotionsStatus, error) {
+	var groupedMotionsStatus groupedMotionsStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/grouped_motion", &groupedMotionsStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &groupedMotionsStatus, nil
+}
+
+func (plugin *HueBridge) fetchGroupedLightLevels(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*groupedLightLevelsStatus, error) {
+	var groupedLightLevelsStatus groupedLightLevelsStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/grouped_light_level", &groupedLightLevelsStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &groupedLightLevelsStatus, nil
+}
+
 func (plugin *HueBridge) fetchDevicePowers(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*devicePowersStatus, error) {
 	var devicePowersStatus devicePowersStatus
 
@@ -410,6 +2281,146 @@ func (plugin *HueBridge) fetchDevicePowers(a telegraf.Accumulator, bridgeUrl str
 	return &devicePowersStatus, nil
 }
 
+func (plugin *HueBridge) fetchDeviceSoftwareUpdates(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*deviceSoftwareUpdatesStatus, error) {
+	var deviceSoftwareUpdatesStatus deviceSoftwareUpdatesStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/device_software_update", &deviceSoftwareUpdatesStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &deviceSoftwareUpdatesStatus, nil
+}
+
+func (plugin *HueBridge) fetchScenes(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*scenesStatus, error) {
+	var scenesStatus scenesStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/scene", &scenesStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &scenesStatus, nil
+}
+
+func (plugin *HueBridge) fetchSmartScenes(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*smartScenesStatus, error) {
+	var smartScenesStatus smartScenesStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/smart_scene", &smartScenesStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &smartScenesStatus, nil
+}
+
+func (plugin *HueBridge) fetchBehaviorInstances(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*behaviorInstancesStatus, error) {
+	var behaviorInstancesStatus behaviorInstancesStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/behavior_instance", &behaviorInstancesStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &behaviorInstancesStatus, nil
+}
+
+func (plugin *HueBridge) fetchBehaviorScripts(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*behaviorScriptsList, error) {
+	var behaviorScriptsList behaviorScriptsList
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/behavior_script", &behaviorScriptsList)
+	if err != nil {
+		return nil, err
+	}
+	return &behaviorScriptsList, nil
+}
+
+func (plugin *HueBridge) fetchGeolocations(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*geolocationsStatus, error) {
+	var geolocationsStatus geolocationsStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/geolocation", &geolocationsStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &geolocationsStatus, nil
+}
+
+func (plugin *HueBridge) fetchGeofenceClients(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*geofenceClientsList, error) {
+	var geofenceClientsList geofenceClientsList
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/geofence_client", &geofenceClientsList)
+	if err != nil {
+		return nil, err
+	}
+	return &geofenceClientsList, nil
+}
+
+func (plugin *HueBridge) fetchEntertainmentConfigurations(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*entertainmentConfigurationsStatus, error) {
+	var entertainmentConfigurationsStatus entertainmentConfigurationsStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/entertainment_configuration", &entertainmentConfigurationsStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &entertainmentConfigurationsStatus, nil
+}
+
+func (plugin *HueBridge) fetchButtons(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*buttonsStatus, error) {
+	var buttonsStatus buttonsStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/button", &buttonsStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &buttonsStatus, nil
+}
+
+func (plugin *HueBridge) fetchRelativeRotaries(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*relativeRotariesStatus, error) {
+	var relativeRotariesStatus relativeRotariesStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/relative_rotary", &relativeRotariesStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &relativeRotariesStatus, nil
+}
+
+func (plugin *HueBridge) fetchContacts(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*contactsStatus, error) {
+	var contactsStatus contactsStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/contact", &contactsStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &contactsStatus, nil
+}
+
+func (plugin *HueBridge) fetchTampers(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*tampersStatus, error) {
+	var tampersStatus tampersStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/tamper", &tampersStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &tampersStatus, nil
+}
+
+func (plugin *HueBridge) fetchZigbeeConnectivities(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*zigbeeConnectivitiesStatus, error) {
+	var zigbeeConnectivitiesStatus zigbeeConnectivitiesStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/zigbee_connectivity", &zigbeeConnectivitiesStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &zigbeeConnectivitiesStatus, nil
+}
+
+func (plugin *HueBridge) fetchZgpConnectivities(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*zgpConnectivitiesStatus, error) {
+	var zgpConnectivitiesStatus zgpConnectivitiesStatus
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/zgp_connectivity", &zgpConnectivitiesStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &zgpConnectivitiesStatus, nil
+}
+
 func (plugin *HueBridge) fetchDevices(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*devicesList, error) {
 	var devicesList devicesList
 
@@ -430,16 +2441,48 @@ func (plugin *HueBridge) fetchRooms(a telegraf.Accumulator, bridgeUrl string, ap
 	return &roomsList, nil
 }
 
-func (plugin *HueBridge) fetchJSON(bridgeUrl string, applicationKey string, path string, v interface{}) (*url.URL, error) {
-	baseUrl, err := url.Parse(bridgeUrl)
+func (plugin *HueBridge) fetchZones(a telegraf.Accumulator, bridgeUrl string, applicationKey string) (*roomsList, error) {
+	var zonesList roomsList
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, "/clip/v2/resource/zone", &zonesList)
 	if err != nil {
 		return nil, err
 	}
-	pathUrl, err := url.Parse(path)
+	return &zonesList, nil
+}
+
+func (plugin *HueBridge) fetchJSON(bridgeUrl string, applicationKey string, path string, v interface{}) (*url.URL, error) {
+	snapshot := plugin.getSnapshot(bridgeUrl)
+	if snapshot != nil {
+		jsonUrl, err := resolveUrl(bridgeUrl, path)
+		if err != nil {
+			return nil, err
+		}
+		handled, err := snapshot.decode(path, v)
+		if handled {
+			return jsonUrl, err
+		}
+	}
+	// the requests are limited per bridge, hence a slow bridge does not hold up the others
+	requestSlots := plugin.getRequestSlots(plugin.bridgeConfig(bridgeUrl))
+	requestSlots <- struct{}{}
+	defer func() { <-requestSlots }()
+	start := time.Now()
+	jsonUrl, err := plugin.doFetchJSON(bridgeUrl, applicationKey, path, v)
+	if errors.Is(err, errResourceNotFound) {
+		// resource types unknown to the bridge's firmware are not a request failure
+		plugin.recordRequest(bridgeUrl, time.Since(start), nil)
+	} else {
+		plugin.recordRequest(bridgeUrl, time.Since(start), err)
+	}
+	return jsonUrl, err
+}
+
+func (plugin *HueBridge) doFetchJSON(bridgeUrl string, applicationKey string, path string, v interface{}) (*url.URL, error) {
+	jsonUrl, err := resolveUrl(bridgeUrl, path)
 	if err != nil {
 		return nil, err
 	}
-	jsonUrl := baseUrl.ResolveReference(pathUrl)
 	if plugin.Debug {
 		plugin.Log.Infof("Fetching JSON from: %s", jsonUrl)
 	}
@@ -447,31 +2490,100 @@ func (plugin *HueBridge) fetchJSON(bridgeUrl string, applicationKey string, path
 	if err != nil {
 		return jsonUrl, err
 	}
-	request.Header.Add("hue-application-key", applicationKey)
-	client := plugin.getClient()
+	if applicationKey != "" {
+		request.Header.Add("hue-application-key", applicationKey)
+	}
+	client, err := plugin.getClient(plugin.bridgeConfig(bridgeUrl))
+	if err != nil {
+		return jsonUrl, err
+	}
 	response, err := client.Do(request)
 	if err != nil {
 		return jsonUrl, err
 	}
 	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return jsonUrl, fmt.Errorf("failed to retrieve json data from %s (%w)", jsonUrl, errResourceNotFound)
+	}
 	if response.StatusCode != http.StatusOK {
 		return jsonUrl, fmt.Errorf("failed to retrieve json data from %s (%s)", jsonUrl, response.Status)
 	}
 	return jsonUrl, json.NewDecoder(response.Body).Decode(v)
 }
 
-func (plugin *HueBridge) getClient() *http.Client {
-	if plugin.cachedClient == nil {
-		transport := &http.Transport{
-			ResponseHeaderTimeout: time.Duration(plugin.Timeout) * time.Second,
-			TLSClientConfig:       &tls.Config{InsecureSkipVerify: true},
+var errResourceNotFound = errors.New("resource not found")
+
+func resolveUrl(bridgeUrl string, path string) (*url.URL, error) {
+	baseUrl, err := url.Parse(bridgeUrl)
+	if err != nil {
+		return nil, err
+	}
+	pathUrl, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	return baseUrl.ResolveReference(pathUrl), nil
+}
+
+func (plugin *HueBridge) getRequestSlots(bridge *BridgeConfig) chan struct{} {
+	bridge.requestSlotsOnce.Do(func() {
+		bridge.requestSlots = make(chan struct{}, max(plugin.MaxConcurrentRequests, 1))
+	})
+	return bridge.requestSlots
+}
+
+// getClient gets the bridge's http client. Only successfully created clients are cached, hence a
+// failure (e.g. an unreadable tls_ca file) is retried on next use.
+func (plugin *HueBridge) getClient(bridge *BridgeConfig) (*http.Client, error) {
+	bridge.cachedClientLock.Lock()
+	defer bridge.cachedClientLock.Unlock()
+	if bridge.cachedClient == nil {
+		transport, err := plugin.createTransport(bridge)
+		if err != nil {
+			return nil, err
 		}
-		plugin.cachedClient = &http.Client{
+		bridge.cachedClient = &http.Client{
 			Transport: transport,
-			Timeout:   time.Duration(plugin.Timeout) * time.Second,
+			Timeout:   time.Duration(bridge.Timeout) * time.Second,
 		}
 	}
-	return plugin.cachedClient
+	return bridge.cachedClient, nil
+}
+
+// bridgeAccumulator tags all metrics with the id (and name) of the bridge they originate from
+type bridgeAccumulator struct {
+	telegraf.Accumulator
+	plugin     *HueBridge
+	bridgeId   string
+	bridgeName string
+}
+
+func (a *bridgeAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if a.plugin.isMetricDue(measurement, fields, a.addBridgeTag(tags)) {
+		a.Accumulator.AddFields(measurement, fields, tags, t...)
+	}
+}
+
+func (a *bridgeAccumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if a.plugin.isMetricDue(measurement, fields, a.addBridgeTag(tags)) {
+		a.Accumulator.AddGauge(measurement, fields, tags, t...)
+	}
+}
+
+func (a *bridgeAccumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	if a.plugin.isMetricDue(measurement, fields, a.addBridgeTag(tags)) {
+		a.Accumulator.AddCounter(measurement, fields, tags, t...)
+	}
+}
+
+func (a *bridgeAccumulator) addBridgeTag(tags map[string]string) map[string]string {
+	if a.bridgeId != "" {
+		tags["huebridge_id"] = a.bridgeId
+	}
+	if a.bridgeName != "" {
+		tags["huebridge_name"] = a.bridgeName
+	}
+	return tags
 }
 
 func init() {
@@ -8,12 +8,30 @@
 package huebridge
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"log"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf/config"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -43,6 +61,7 @@ func TestGather1(t *testing.T) {
 	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
 	plugin.Log = createDummyLogger()
 	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
 
 	var a testutil.Accumulator
 
@@ -52,297 +71,2872 @@ func TestGather1(t *testing.T) {
 	require.True(t, a.HasMeasurement("huebridge_light_level"))
 	require.True(t, a.HasMeasurement("huebridge_motion"))
 	require.True(t, a.HasMeasurement("huebridge_device_power"))
+	require.True(t, a.HasMeasurement("huebridge_button"))
+	require.True(t, a.HasMeasurement("huebridge_rotary"))
+	require.True(t, a.HasMeasurement("huebridge_contact"))
+	require.True(t, a.HasMeasurement("huebridge_tamper"))
+	require.True(t, a.HasMeasurement("huebridge_connectivity"))
+	require.True(t, a.HasMeasurement("huebridge_grouped_light"))
+	require.True(t, a.HasMeasurement("huebridge_bridge"))
+	require.True(t, a.HasMeasurement("huebridge_device_info"))
+	require.True(t, a.HasMeasurement("huebridge_software_update"))
+	require.True(t, a.HasMeasurement("huebridge_scene"))
+	require.True(t, a.HasMeasurement("huebridge_automation"))
+	require.True(t, a.HasMeasurement("huebridge_entertainment"))
+	require.True(t, a.HasMeasurement("huebridge_presence"))
+	require.True(t, a.HasMeasurement("huebridge_grouped_motion"))
+	require.True(t, a.HasMeasurement("huebridge_grouped_light_level"))
 }
 
-func TestGather2(t *testing.T) {
+func TestGatherBridge(t *testing.T) {
 	testServerHandler := &testServerHandler{Debug: true}
 	testServer := httptest.NewServer(testServerHandler)
 	defer testServer.Close()
 	plugin := NewHueBridge()
-	plugin.Bridges = [][]string{{testServer.URL, "invalid_applicationkey"}}
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	for _, metric := range a.GetTelegrafMetrics() {
+		require.Equal(t, "001788fffe0a0b0c", metric.Tags()["huebridge_id"])
+		if metric.Name() == "huebridge_bridge" {
+			fields := metric.Fields()
+			require.Equal(t, "huebridge1", fields["name"])
+			require.Equal(t, "BSB002", fields["model_id"])
+			require.Equal(t, "1962154010", fields["software_version"])
+			require.Equal(t, "1.62.0", fields["api_version"])
+			require.Equal(t, "00:17:88:0a:0b:0c", fields["mac_address"])
+			require.Equal(t, int64(25), fields["zigbee_channel"])
+			require.Greater(t, fields["requests"], int64(0))
+			require.Equal(t, int64(0), fields["request_errors"])
+			require.Contains(t, fields, "request_latency_ms")
+		}
+	}
+}
+
+func TestGatherBridgeUnavailable(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, UnavailablePath: "/clip/v2/resource/bridge"}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
 	plugin.Log = createDummyLogger()
 	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
 
 	var a testutil.Accumulator
 
+	// the bridge resource is not available, all other measurements are reported nevertheless
 	require.Error(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("huebridge_light"))
+	require.True(t, a.HasMeasurement("huebridge_bridge"))
+	require.False(t, a.HasTag("huebridge_light", "huebridge_id"))
+	a.ClearMetrics()
+	a.Errors = nil
+	testServerHandler.UnavailablePath = ""
+	require.NoError(t, a.GatherError(plugin.Gather))
+	// the bridge is not available at all, the bridge stats are reported with the last known bridge ID
+	testServerHandler.UnavailablePath = "/clip/v2/"
+	for i := 0; i < 3; i++ {
+		a.ClearMetrics()
+		a.Errors = nil
+		require.Error(t, a.GatherError(plugin.Gather))
+		metrics := a.GetTelegrafMetrics()
+		require.Len(t, metrics, 1)
+		require.Equal(t, "huebridge_bridge", metrics[0].Name())
+		require.Equal(t, "001788fffe0a0b0c", metrics[0].Tags()["huebridge_id"])
+		// the bridge, device, room and zone requests failed, the bridge config is cached during the 1st
+		// gather and re-fetched afterwards (as requests have failed)
+		requests, _ := metrics[0].GetField("requests")
+		if i == 0 {
+			require.Equal(t, int64(4), requests)
+		} else {
+			require.Equal(t, int64(5), requests)
+		}
+		_, hasName := metrics[0].GetField("name")
+		require.True(t, hasName)
+		requestErrors, _ := metrics[0].GetField("request_errors")
+		require.Equal(t, int64(4), requestErrors)
+	}
 }
 
-func createDummyLogger() *dummyLogger {
-	log.SetOutput(os.Stderr)
-	return &dummyLogger{}
+func TestGatherLightFields(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_light" && metric.Tags()["huebridge_device"] == "Lamp 4" {
+			require.Equal(t, map[string]interface{}{
+				"on":                            int64(1),
+				"mode":                          "normal",
+				"brightness":                    50.2,
+				"color_temperature_mirek_valid": int64(1),
+				"color_temperature_mirek":       int64(370),
+				"color_temperature_kelvin":      int64(2702),
+				"color_x":                       0.4573,
+				"color_y":                       0.41,
+				"color_gamut_type":              "C",
+				"color_hex":                     "#fbab59",
+				"dynamics_status":               "none",
+				"effects_status":                "no_effect",
+			}, metric.Fields())
+		}
+	}
 }
 
-type dummyLogger struct{}
+func TestGatherButtonFields(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
 
-func (l *dummyLogger) Errorf(format string, args ...interface{}) {
-	log.Printf(format, args...)
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_button", map[string]interface{}{
+		"last_event": "short_release",
+		"updated":    "2024-01-23T10:00:00.123Z",
+		"presses":    0,
+	}, map[string]string{
+		"huebridge_url":        testServer.URL,
+		"huebridge_id":         "001788fffe0a0b0c",
+		"huebridge_room":       "<unassigned>",
+		"huebridge_device":     "Tap dial switch",
+		"huebridge_control_id": "1",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_rotary", map[string]interface{}{
+		"last_event":         "start",
+		"updated":            "2024-01-23T10:00:01.456Z",
+		"rotation_direction": "clock_wise",
+		"rotation_steps":     30,
+		"rotation_duration":  400,
+		"rotations":          0,
+	}, map[string]string{
+		"huebridge_url":    testServer.URL,
+		"huebridge_id":     "001788fffe0a0b0c",
+		"huebridge_room":   "<unassigned>",
+		"huebridge_device": "Tap dial switch",
+	})
 }
 
-func (l *dummyLogger) Error(args ...interface{}) {
-	log.Print(args...)
+func TestGatherContactFields(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_contact", map[string]interface{}{
+		"contact": 0,
+		"state":   "no_contact",
+		"changed": "2024-01-23T09:45:12.345Z",
+	}, map[string]string{
+		"huebridge_url":    testServer.URL,
+		"huebridge_id":     "001788fffe0a0b0c",
+		"huebridge_room":   "<unassigned>",
+		"huebridge_device": "Door sensor",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_tamper", map[string]interface{}{
+		"tampered": 0,
+		"state":    "not_tampered",
+		"changed":  "2024-01-22T18:30:00.000Z",
+	}, map[string]string{
+		"huebridge_url":           testServer.URL,
+		"huebridge_id":            "001788fffe0a0b0c",
+		"huebridge_room":          "<unassigned>",
+		"huebridge_device":        "Door sensor",
+		"huebridge_tamper_source": "battery_door",
+	})
 }
 
-func (l *dummyLogger) Debugf(format string, args ...interface{}) {
-	log.Printf(format, args...)
+func TestGatherSoftwareUpdateFields(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 2, countMeasurement(&a, "huebridge_software_update"))
+	a.AssertContainsTaggedFields(t, "huebridge_software_update", map[string]interface{}{
+		"state":          "update_pending",
+		"state_duration": int64(0),
+		"problems":       "",
+	}, map[string]string{
+		"huebridge_url":    testServer.URL,
+		"huebridge_id":     "001788fffe0a0b0c",
+		"huebridge_device": "Lamp 4",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_software_update", map[string]interface{}{
+		"state":          "no_update",
+		"state_duration": int64(0),
+		"problems":       "battery_too_low",
+	}, map[string]string{
+		"huebridge_url":    testServer.URL,
+		"huebridge_id":     "001788fffe0a0b0c",
+		"huebridge_device": "Motion sensor",
+	})
 }
 
-func (l *dummyLogger) Debug(args ...interface{}) {
-	log.Print(args...)
+func TestGatherDeviceInfo(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.DeviceTags = []string{"model", "archetype"}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_device_info", map[string]interface{}{
+		"archetype":         "sultan_bulb",
+		"model_id":          "LCA001",
+		"manufacturer_name": "Signify Netherlands B.V.",
+		"product_name":      "Hue color lamp",
+		"product_archetype": "sultan_bulb",
+		"software_version":  "1.104.2",
+		"certified":         1,
+	}, map[string]string{
+		"huebridge_url":       testServer.URL,
+		"huebridge_id":        "001788fffe0a0b0c",
+		"huebridge_room":      "Flur",
+		"huebridge_device":    "Lamp 4",
+		"huebridge_model":     "LCA001",
+		"huebridge_archetype": "sultan_bulb",
+	})
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_light" && metric.Tags()["huebridge_device"] == "Lamp 4" {
+			require.Equal(t, "LCA001", metric.Tags()["huebridge_model"])
+			require.Equal(t, "sultan_bulb", metric.Tags()["huebridge_archetype"])
+		}
+	}
+	// device info is only reported once per interval
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.False(t, a.HasMeasurement("huebridge_device_info"))
+	plugin.DeviceInfoInterval = 0
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("huebridge_device_info"))
+	plugin.DeviceTags = []string{"invalid"}
+	require.Error(t, plugin.Init())
 }
 
-func (l *dummyLogger) Warnf(format string, args ...interface{}) {
-	log.Printf(format, args...)
+func TestGatherGroupedLights(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_grouped_light", map[string]interface{}{
+		"on":         1,
+		"brightness": 75.5,
+		"lights":     3,
+		"lights_on":  3,
+	}, map[string]string{
+		"huebridge_url":  testServer.URL,
+		"huebridge_id":   "001788fffe0a0b0c",
+		"huebridge_room": "Flur",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_grouped_light", map[string]interface{}{
+		"on":         1,
+		"brightness": 50.2,
+		"lights":     2,
+		"lights_on":  1,
+	}, map[string]string{
+		"huebridge_url":  testServer.URL,
+		"huebridge_id":   "001788fffe0a0b0c",
+		"huebridge_zone": "Downstairs",
+	})
 }
 
-func (l *dummyLogger) Warn(args ...interface{}) {
-	log.Print(args...)
+func TestGatherGroupedSensors(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_grouped_motion", map[string]interface{}{
+		"motion": 1,
+	}, map[string]string{
+		"huebridge_url":  testServer.URL,
+		"huebridge_id":   "001788fffe0a0b0c",
+		"huebridge_room": "Flur",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_grouped_light_level", map[string]interface{}{
+		"light_level":     float32(18000),
+		"light_level_lux": lightLevelToLux(18000),
+	}, map[string]string{
+		"huebridge_url":  testServer.URL,
+		"huebridge_id":   "001788fffe0a0b0c",
+		"huebridge_room": "Flur",
+	})
+	// the bridge_home groups are skipped
+	require.Equal(t, 1, countMeasurement(&a, "huebridge_grouped_motion"))
+	require.Equal(t, 1, countMeasurement(&a, "huebridge_grouped_light_level"))
 }
 
-func (l *dummyLogger) Infof(format string, args ...interface{}) {
-	log.Printf(format, args...)
+func TestGatherReportTimestamps(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	plugin.ReportTimestamps = true
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	changed := time.Date(2024, 1, 23, 10, 0, 0, 0, time.UTC)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_grouped_motion" || metric.Name() == "huebridge_grouped_light_level" {
+			require.True(t, changed.Equal(metric.Time()))
+		}
+	}
+	require.Equal(t, 1, countMeasurement(&a, "huebridge_grouped_motion"))
+	require.Equal(t, 1, countMeasurement(&a, "huebridge_grouped_light_level"))
+	a.ClearMetrics()
+	// unchanged reports are not emitted again, resources without report are
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 0, countMeasurement(&a, "huebridge_grouped_motion"))
+	require.Equal(t, 0, countMeasurement(&a, "huebridge_grouped_light_level"))
+	require.Equal(t, 1, countMeasurement(&a, "huebridge_temperature"))
 }
 
-func (l *dummyLogger) Info(args ...interface{}) {
-	log.Print(args...)
+func TestGatherEmitChanges(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	plugin.Emit = "changes"
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	// the device info is only reported once per device_info_interval
+	firstCount := len(a.GetTelegrafMetrics()) - countMeasurement(&a, "huebridge_device_info")
+	require.True(t, a.HasMeasurement("huebridge_light"))
+	a.ClearMetrics()
+	// nothing has changed, only the bridge stats are emitted
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 1, len(a.GetTelegrafMetrics()))
+	require.True(t, a.HasMeasurement("huebridge_bridge"))
+	a.ClearMetrics()
+	// heartbeat interval elapsed, everything is emitted again
+	for _, snapshot := range plugin.emittedSnapshots {
+		snapshot.emitted = snapshot.emitted.Add(-time.Duration(plugin.HeartbeatInterval) * time.Second)
+	}
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, firstCount, len(a.GetTelegrafMetrics()))
+	a.ClearMetrics()
+	// series not seen during a gather (e.g. deleted devices) are dropped
+	hasSnapshot := func(measurement string) bool {
+		for _, snapshot := range plugin.emittedSnapshots {
+			if snapshot.measurement == measurement {
+				return true
+			}
+		}
+		return false
+	}
+	require.True(t, hasSnapshot("huebridge_temperature"))
+	require.False(t, hasSnapshot("huebridge_device_info"))
+	testServerHandler.UnavailablePath = "/clip/v2/resource/temperature"
+	require.Error(t, a.GatherError(plugin.Gather))
+	require.False(t, hasSnapshot("huebridge_temperature"))
+	require.True(t, hasSnapshot("huebridge_light"))
+	a.ClearMetrics()
+	a.Errors = nil
+	// and emitted again as soon as they re-appear
+	testServerHandler.UnavailablePath = ""
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, countMeasurement(&a, "huebridge_temperature")+1, len(a.GetTelegrafMetrics()))
+	require.True(t, a.HasMeasurement("huebridge_temperature"))
+	plugin.Emit = "invalid"
+	require.Error(t, plugin.Init())
 }
 
-type testServerHandler struct {
-	Debug bool
+func TestIsMetricDue(t *testing.T) {
+	plugin := NewHueBridge()
+	plugin.Emit = "changes"
+	tags := map[string]string{"huebridge_url": "https://127.0.0.1", "huebridge_device": "Door"}
+	require.True(t, plugin.isMetricDue("huebridge_contact", map[string]interface{}{"contact": 1, "changed": "2024-01-23T10:00:00.000Z"}, tags))
+	// the report timestamp is not considered a change
+	require.False(t, plugin.isMetricDue("huebridge_contact", map[string]interface{}{"contact": 1, "changed": "2024-01-23T10:00:01.000Z"}, tags))
+	require.True(t, plugin.isMetricDue("huebridge_contact", map[string]interface{}{"contact": 0, "changed": "2024-01-23T10:00:02.000Z"}, tags))
+	require.True(t, plugin.isMetricDue("huebridge_button", map[string]interface{}{"presses": 0, "updated": "2024-01-23T10:00:00.000Z"}, tags))
+	require.False(t, plugin.isMetricDue("huebridge_button", map[string]interface{}{"presses": 0, "updated": "2024-01-23T10:00:01.000Z"}, tags))
+	require.True(t, plugin.isMetricDue("huebridge_button", map[string]interface{}{"presses": 1, "updated": "2024-01-23T10:00:02.000Z"}, tags))
+	// the bridge stats are always due
+	require.True(t, plugin.isMetricDue("huebridge_bridge", map[string]interface{}{"requests": 1}, tags))
+	require.True(t, plugin.isMetricDue("huebridge_bridge", map[string]interface{}{"requests": 1}, tags))
 }
 
-func (tsh *testServerHandler) ServeHTTP(out http.ResponseWriter, request *http.Request) {
-	requestURL := request.URL.String()
-	if tsh.Debug {
-		log.Printf("test: request URL: %s", requestURL)
+func TestGatherConcurrently(t *testing.T) {
+	testServerHandler1 := &testServerHandler{Debug: true, Delay: 5 * time.Millisecond}
+	testServer1 := httptest.NewServer(testServerHandler1)
+	defer testServer1.Close()
+	testServerHandler2 := &testServerHandler{Debug: true, Delay: 5 * time.Millisecond}
+	testServer2 := httptest.NewServer(testServerHandler2)
+	defer testServer2.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer1.URL, "applicationkey"}, {testServer2.URL, "applicationkey"}}
+	plugin.MaxConcurrentRequests = 3
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler1.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Empty(t, a.Errors)
+	bridgeUrls := make(map[string]bool)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_bridge" {
+			bridgeUrls[metric.Tags()["huebridge_url"]] = true
+		}
 	}
-	if request.Header.Get("hue-application-key") != "applicationkey" {
-		out.WriteHeader(http.StatusUnauthorized)
-	} else if requestURL == "/clip/v2/resource/light" {
-		tsh.serveResourceLight(out, request)
-	} else if requestURL == "/clip/v2/resource/temperature" {
-		tsh.serveResourceTemperature(out, request)
-	} else if requestURL == "/clip/v2/resource/light_level" {
-		tsh.serveResourceLightLevel(out, request)
-	} else if requestURL == "/clip/v2/resource/motion" {
-		tsh.serveResourceMotion(out, request)
-	} else if requestURL == "/clip/v2/resource/device_power" {
-		tsh.serveResourceDevicePower(out, request)
-	} else if requestURL == "/clip/v2/resource/device" {
-		tsh.serveResourceDevice(out, request)
-	} else if requestURL == "/clip/v2/resource/room" {
-		tsh.serveResourceRoom(out, request)
+	require.Equal(t, map[string]bool{testServer1.URL: true, testServer2.URL: true}, bridgeUrls)
+	// the limit applies per bridge
+	require.Greater(t, testServerHandler1.maxActiveRequests.Load(), int32(1))
+	require.LessOrEqual(t, testServerHandler1.maxActiveRequests.Load(), int32(3))
+	require.Greater(t, testServerHandler2.maxActiveRequests.Load(), int32(1))
+	require.LessOrEqual(t, testServerHandler2.maxActiveRequests.Load(), int32(3))
+	plugin.MaxConcurrentRequests = 0
+	require.Error(t, plugin.Init())
+}
+
+func TestGatherSlowBridge(t *testing.T) {
+	slowTestServerHandler := &testServerHandler{Debug: true, Delay: 50 * time.Millisecond}
+	slowTestServer := httptest.NewServer(slowTestServerHandler)
+	defer slowTestServer.Close()
+	fastTestServerHandler := &testServerHandler{Debug: true}
+	fastTestServer := httptest.NewServer(fastTestServerHandler)
+	defer fastTestServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{slowTestServer.URL, "applicationkey"}, {fastTestServer.URL, "applicationkey"}}
+	plugin.MaxConcurrentRequests = 1
+	plugin.Log = createDummyLogger()
+	plugin.Debug = fastTestServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	start := time.Now()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Empty(t, a.Errors)
+	bridgeTimes := make(map[string]time.Duration)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_bridge" {
+			bridgeTimes[metric.Tags()["huebridge_url"]] = metric.Time().Sub(start)
+		}
 	}
+	require.Len(t, bridgeTimes, 2)
+	// the fast bridge does not have to wait for the request slots of the slow bridge
+	require.Equal(t, int32(1), slowTestServerHandler.maxActiveRequests.Load())
+	require.Equal(t, int32(1), fastTestServerHandler.maxActiveRequests.Load())
+	require.Less(t, bridgeTimes[fastTestServer.URL], 5*slowTestServerHandler.Delay)
+	require.Greater(t, bridgeTimes[slowTestServer.URL], 5*slowTestServerHandler.Delay)
 }
 
-const testResourceLight = `
+func TestGatherBridgeConfigs(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer1 := httptest.NewServer(testServerHandler)
+	defer testServer1.Close()
+	testServer2 := httptest.NewServer(testServerHandler)
+	defer testServer2.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer1.URL, "applicationkey"}}
+	plugin.BridgeConfigs = []*BridgeConfig{{
+		Url:             testServer2.URL,
+		ApplicationKey:  config.NewSecret([]byte("applicationkey")),
+		Name:            "upstairs",
+		Resources:       []string{"light", "temperature"},
+		RoomAssignments: [][]string{{"Attic", "Motion sensor"}},
+	}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Empty(t, a.Errors)
+	measurements := make(map[string]map[string]bool)
+	measurements[testServer1.URL] = make(map[string]bool)
+	measurements[testServer2.URL] = make(map[string]bool)
+	for _, metric := range a.GetTelegrafMetrics() {
+		bridgeUrl := metric.Tags()["huebridge_url"]
+		measurements[bridgeUrl][metric.Name()] = true
+		if bridgeUrl == testServer2.URL {
+			require.Equal(t, "upstairs", metric.Tags()["huebridge_name"])
+		} else {
+			require.NotContains(t, metric.Tags(), "huebridge_name")
+		}
+	}
+	require.True(t, measurements[testServer1.URL]["huebridge_motion"])
+	require.True(t, measurements[testServer2.URL]["huebridge_light"])
+	require.True(t, measurements[testServer2.URL]["huebridge_temperature"])
+	require.False(t, measurements[testServer2.URL]["huebridge_motion"])
+	require.False(t, measurements[testServer2.URL]["huebridge_grouped_light"])
+	// the room assignments of the bridge config only apply to this bridge
+	a.AssertContainsTaggedFields(t, "huebridge_temperature", map[string]interface{}{
+		"temperature": float32(20.45),
+	}, map[string]string{
+		"huebridge_url":    testServer2.URL,
+		"huebridge_id":     "001788fffe0a0b0c",
+		"huebridge_name":   "upstairs",
+		"huebridge_room":   "Attic",
+		"huebridge_device": "Motion sensor",
+	})
+	// invalid bridge configs are rejected during Init
+	plugin.BridgeConfigs = []*BridgeConfig{{Url: testServer2.URL}}
+	require.ErrorContains(t, plugin.Init(), "application_key")
+	plugin.BridgeConfigs = []*BridgeConfig{{Url: testServer2.URL, ApplicationKey: config.NewSecret([]byte("applicationkey")), Resources: []string{"invalid"}}}
+	require.ErrorContains(t, plugin.Init(), "resources")
+	plugin.BridgeConfigs = []*BridgeConfig{{Url: testServer1.URL, ApplicationKey: config.NewSecret([]byte("applicationkey"))}}
+	require.ErrorContains(t, plugin.Init(), "Duplicate")
+	plugin.BridgeConfigs = []*BridgeConfig{{Url: testServer2.URL, ApplicationKey: config.NewSecret([]byte("applicationkey")), TLSVerify: "invalid"}}
+	require.ErrorContains(t, plugin.Init(), "tls_verify")
+	plugin.Bridges = [][]string{}
+	plugin.BridgeConfigs = nil
+	require.ErrorContains(t, plugin.Init(), "Empty bridge list")
+}
+
+func TestGatherApplicationKeys(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	t.Setenv("HUEBRIDGE_APPLICATION_KEY", "applicationkey")
+	applicationKeyFile := filepath.Join(t.TempDir(), "applicationkey")
+	require.NoError(t, os.WriteFile(applicationKeyFile, []byte("applicationkey\n"), 0600))
+	logger := createDummyLogger()
+	gatherApplicationKey := func(bridge *BridgeConfig) (*testutil.Accumulator, error) {
+		plugin := NewHueBridge()
+		bridge.Url = testServer.URL
+		plugin.BridgeConfigs = []*BridgeConfig{bridge}
+		plugin.Log = logger
+		plugin.Debug = testServerHandler.Debug
+		err := plugin.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		var a testutil.Accumulator
+
+		return &a, a.GatherError(plugin.Gather)
+	}
+	// secret
+	a, err := gatherApplicationKey(&BridgeConfig{ApplicationKey: config.NewSecret([]byte("applicationkey"))})
+	require.NoError(t, err)
+	require.True(t, a.HasMeasurement("huebridge_light"))
+	// environment variable
+	a, err = gatherApplicationKey(&BridgeConfig{ApplicationKeyEnv: "HUEBRIDGE_APPLICATION_KEY"})
+	require.NoError(t, err)
+	require.True(t, a.HasMeasurement("huebridge_light"))
+	// key file
+	a, err = gatherApplicationKey(&BridgeConfig{ApplicationKeyFile: applicationKeyFile})
+	require.NoError(t, err)
+	require.True(t, a.HasMeasurement("huebridge_light"))
+	_, err = gatherApplicationKey(&BridgeConfig{ApplicationKeyEnv: "HUEBRIDGE_UNDEFINED_APPLICATION_KEY"})
+	require.Error(t, err)
+	_, err = gatherApplicationKey(&BridgeConfig{ApplicationKeyFile: filepath.Join(t.TempDir(), "undefined")})
+	require.Error(t, err)
+	// unresolved secret-store reference
+	_, err = gatherApplicationKey(&BridgeConfig{ApplicationKey: config.NewSecret([]byte("@{store:applicationkey}"))})
+	require.Error(t, err)
+	// exactly one key source is required
+	_, err = gatherApplicationKey(&BridgeConfig{})
+	require.ErrorContains(t, err, "Missing application_key")
+	_, err = gatherApplicationKey(&BridgeConfig{ApplicationKey: config.NewSecret([]byte("applicationkey")), ApplicationKeyEnv: "HUEBRIDGE_APPLICATION_KEY"})
+	require.ErrorContains(t, err, "Only one of")
+	// the key is neither logged nor part of any error
+	var logOutput bytes.Buffer
+	log.SetOutput(&logOutput)
+	defer log.SetOutput(os.Stderr)
+	a, err = gatherApplicationKey(&BridgeConfig{ApplicationKey: config.NewSecret([]byte("invalid_applicationkey"))})
+	log.SetOutput(os.Stderr)
+	require.Error(t, err)
+	require.NotEmpty(t, logOutput.String())
+	require.NotContains(t, logOutput.String(), "invalid_applicationkey")
+	for _, err := range a.Errors {
+		require.NotContains(t, err.Error(), "invalid_applicationkey")
+	}
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey", "invalid_applicationkey"}}
+	err = plugin.Init()
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "applicationkey")
+}
+
+func TestGatherSnapshot(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	plugin.DeviceInfoInterval = 0
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	measurements := make(map[string]bool)
+	for _, metric := range a.GetTelegrafMetrics() {
+		measurements[metric.Name()] = true
+	}
+	a.ClearMetrics()
+	plugin.Snapshot = true
+	// the bridge config is cached for the device info interval (the device info is reported nevertheless)
+	plugin.DeviceInfoInterval = 3600
+	plugin.deviceInfoReported = nil
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Empty(t, a.Errors)
+	snapshotMeasurements := make(map[string]bool)
+	for _, metric := range a.GetTelegrafMetrics() {
+		snapshotMeasurements[metric.Name()] = true
+		if metric.Name() == "huebridge_bridge" {
+			// only the snapshot
+			requests, _ := metric.GetField("requests")
+			require.Equal(t, int64(1), requests)
+			name, _ := metric.GetField("name")
+			require.Equal(t, "huebridge1", name)
+			zigbeeChannel, _ := metric.GetField("zigbee_channel")
+			require.Equal(t, int64(25), zigbeeChannel)
+		}
+	}
+	require.Equal(t, measurements, snapshotMeasurements)
+	require.Equal(t, 5, countMeasurement(&a, "huebridge_light"))
+	require.Empty(t, plugin.snapshots)
+}
+
+func TestPair(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	pairPollInterval = 10 * time.Millisecond
+
+	_, err := Pair(testServer.URL, "test", 15*time.Millisecond)
+	require.Error(t, err)
+	applicationKey, err := Pair(testServer.URL, "test", time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "applicationkey", applicationKey)
+	config := PairedConfig(testServer.URL, applicationKey)
+	require.Contains(t, config, "[[inputs.huebridge]]")
+	require.Contains(t, config, `bridges = [["`+testServer.URL+`", "applicationkey"]]`)
+}
+
+func TestGatherAutoBridge(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	discoveries := 0
+	discoverBridges = func(timeout time.Duration) ([]DiscoveredBridge, error) {
+		discoveries++
+		return []DiscoveredBridge{{BridgeId: "001788fffe0a0b0c", Address: "127.0.0.1", Model: "BSB002", Url: testServer.URL}}, nil
+	}
+	defer func() { discoverBridges = Discover }()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{"auto:001788FFFE0A0B0C", "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Empty(t, a.Errors)
+	for _, metric := range a.GetTelegrafMetrics() {
+		require.Equal(t, testServer.URL, metric.Tags()["huebridge_url"])
+	}
+	require.True(t, a.HasMeasurement("huebridge_bridge"))
+	// the discovery result is reused
+	plugin.Bridges = [][]string{{"auto", "applicationkey"}}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Empty(t, a.Errors)
+	require.Equal(t, 1, discoveries)
+	plugin.Bridges = [][]string{{"auto:0017880000000000", "applicationkey"}}
+	require.NoError(t, plugin.Init())
+	require.Error(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 2, discoveries)
+}
+
+func TestGatherDuplicateBridge(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	discoverBridges = func(timeout time.Duration) ([]DiscoveredBridge, error) {
+		return []DiscoveredBridge{{BridgeId: "001788fffe0a0b0c", Address: "127.0.0.1", Model: "BSB002", Url: testServer.URL}}, nil
+	}
+	defer func() { discoverBridges = Discover }()
+	plugin := NewHueBridge()
+	plugin.BridgeConfigs = []*BridgeConfig{{
+		Url:            testServer.URL,
+		ApplicationKey: config.NewSecret([]byte("applicationkey")),
+		Name:           "explicit",
+	}, {
+		Url:            "auto",
+		ApplicationKey: config.NewSecret([]byte("applicationkey")),
+		Name:           "discovered",
+	}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	// both bridges resolve to the same url, only the 1st one activated is processed
+	require.NoError(t, plugin.Gather(&a))
+	require.Len(t, a.Errors, 1)
+	require.ErrorContains(t, a.Errors[0], "already used by bridge")
+	bridgeNames := make([]string, 0)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_bridge" {
+			bridgeNames = append(bridgeNames, metric.Tags()["huebridge_name"])
+		}
+	}
+	require.Len(t, bridgeNames, 1)
+	for _, metric := range a.GetTelegrafMetrics() {
+		require.Equal(t, bridgeNames[0], metric.Tags()["huebridge_name"])
+	}
+}
+
+func TestGatherTLSVerify(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewTLSServer(testServerHandler)
+	defer testServer.Close()
+	gatherTLS := func(configure func(plugin *HueBridge)) error {
+		plugin := NewHueBridge()
+		plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+		plugin.Log = createDummyLogger()
+		plugin.Debug = testServerHandler.Debug
+		configure(plugin)
+		err := plugin.Init()
+		if err != nil {
+			return err
+		}
+
+		var a testutil.Accumulator
+
+		return a.GatherError(plugin.Gather)
+	}
+	// none
+	require.NoError(t, gatherTLS(func(plugin *HueBridge) {}))
+	// ca
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testServer.Certificate().Raw}), 0600))
+	require.NoError(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = "ca"
+		plugin.TLSCA = caFile
+	}))
+	require.Error(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = "ca"
+	}))
+	// fingerprint
+	fingerprint := sha256.Sum256(testServer.Certificate().Raw)
+	require.NoError(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = "fingerprint"
+		plugin.TLSFingerprints = []string{strings.ToUpper(hex.EncodeToString(fingerprint[:]))}
+	}))
+	require.Error(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = "fingerprint"
+		plugin.TLSFingerprints = []string{strings.Repeat("00", sha256.Size)}
+	}))
+	require.Error(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = "fingerprint"
+		plugin.TLSFingerprints = []string{"invalid"}
+	}))
+	// hue (the test server's certificate is not issued by the Hue root CA)
+	require.Error(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = "hue"
+		plugin.ServerName = "001788fffe0a0b0c"
+	}))
+	require.Error(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = "invalid"
+	}))
+	// verification options without matching tls_verify
+	require.ErrorContains(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSCA = caFile
+	}), "tls_ca option requires")
+	require.ErrorContains(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = ""
+		plugin.TLSCA = caFile
+	}), "tls_ca option requires")
+	require.ErrorContains(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSFingerprints = []string{hex.EncodeToString(fingerprint[:])}
+	}), "tls_fingerprints option requires")
+	require.ErrorContains(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = ""
+		plugin.TLSFingerprints = []string{hex.EncodeToString(fingerprint[:])}
+	}), "tls_fingerprints option requires")
+	require.ErrorContains(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.TLSVerify = "ca"
+		plugin.TLSCA = caFile
+		plugin.InsecureSkipVerify = true
+	}), "insecure_skip_verify option conflicts")
+	require.ErrorContains(t, gatherTLS(func(plugin *HueBridge) {
+		plugin.Bridges = [][]string{}
+		plugin.BridgeConfigs = []*BridgeConfig{{
+			Url:            testServer.URL,
+			ApplicationKey: config.NewSecret([]byte("applicationkey")),
+			TLSVerify:      "ca",
+			ClientConfig:   common_tls.ClientConfig{TLSCA: caFile, InsecureSkipVerify: true},
+		}}
+	}), "insecure_skip_verify option conflicts")
+}
+
+func TestGatherTLSRetry(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewTLSServer(testServerHandler)
+	defer testServer.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	plugin.TLSVerify = "ca"
+	plugin.TLSCA = caFile
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	// the CA file is not yet available
+	require.Error(t, a.GatherError(plugin.Gather))
+	require.False(t, a.HasMeasurement("huebridge_light"))
+	a.Errors = nil
+	// the client creation is retried during the next gather
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testServer.Certificate().Raw}), 0600))
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("huebridge_light"))
+}
+
+func TestGatherTLSVerifyHue(t *testing.T) {
+	caPEM, bridgeCertificate := createTestBridgeCertificates(t, "001788fffe0a0b0c")
+	hueRootCA0 := hueRootCA
+	hueRootCA = caPEM
+	defer func() { hueRootCA = hueRootCA0 }()
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewUnstartedServer(testServerHandler)
+	testServer.TLS = &tls.Config{Certificates: []tls.Certificate{bridgeCertificate}}
+	testServer.StartTLS()
+	defer testServer.Close()
+	discoverBridges = func(timeout time.Duration) ([]DiscoveredBridge, error) {
+		return []DiscoveredBridge{
+			{BridgeId: "001788fffe0a0b0c", Address: "127.0.0.1", Model: "BSB002", Url: testServer.URL},
+			{BridgeId: "0017880000000000", Address: "127.0.0.2", Model: "BSB002", Url: "https://127.0.0.2"},
+		}, nil
+	}
+	defer func() { discoverBridges = Discover }()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{"auto:001788fffe0a0b0c", "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	plugin.TLSVerify = "hue"
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("huebridge_light"))
+	// certificate issued for another bridge
+	plugin = NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	plugin.TLSVerify = "hue"
+	plugin.ServerName = "0017880000000000"
+	require.NoError(t, plugin.Init())
+	a.ClearMetrics()
+	require.Error(t, a.GatherError(plugin.Gather))
+	// bridge id unknown
+	plugin.ServerName = ""
+	require.NoError(t, plugin.Init())
+	require.Error(t, a.GatherError(plugin.Gather))
+}
+
+func TestHueRootCA(t *testing.T) {
+	block, _ := pem.Decode(hueRootCA)
+	require.NotNil(t, block)
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	require.Equal(t, "root-bridge", certificate.Subject.CommonName)
+	require.NoError(t, certificate.CheckSignatureFrom(certificate))
+}
+
+func createTestBridgeCertificates(t *testing.T, bridgeId string) ([]byte, tls.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root-bridge"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	bridgeKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	bridgeTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: bridgeId},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	bridgeDER, err := x509.CreateCertificate(rand.Reader, bridgeTemplate, caTemplate, &bridgeKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	return caPEM, tls.Certificate{Certificate: [][]byte{bridgeDER}, PrivateKey: bridgeKey}
+}
+
+func TestParseMDNSResponse(t *testing.T) {
+	query := mdnsQuery(mdnsService)
+	nameEnd, err := skipMDNSName(query, 12)
+	require.NoError(t, err)
+	require.Equal(t, len(query), nameEnd+4)
+	// a response echoing the question followed by a TXT record using a compressed name
+	response := append([]byte{}, query...)
+	response[7] = 1
+	txtData := []byte("\x19bridgeid=001788fffe0a0b0c\x0emodelid=BSB002")
+	response = append(response, 0xc0, 12, 0, mdnsTypeTXT, 0x80, mdnsClassIN, 0, 0, 0x11, 0x94, 0, byte(len(txtData)))
+	response = append(response, txtData...)
+	txt, err := parseMDNSResponse(response)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"bridgeid": "001788fffe0a0b0c", "modelid": "BSB002"}, txt)
+	_, err = parseMDNSResponse(response[:len(response)-1])
+	require.Error(t, err)
+}
+
+func TestParseSSDPResponse(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+
+	response := "HTTP/1.1 200 OK\r\nHOST: 239.255.255.250:1900\r\nEXT:\r\nCACHE-CONTROL: max-age=100\r\nLOCATION: " + testServer.URL + "/description.xml\r\nSERVER: Hue/1.0 UPnP/1.0 IpBridge/1.62.0\r\nhue-bridgeid: 001788FFFE0A0B0C\r\nST: upnp:rootdevice\r\nUSN: uuid:2f402f80-da50-11e1-9b23-0017880a0b0c::upnp:rootdevice\r\n\r\n"
+	bridgeId, location := parseSSDPResponse([]byte(response))
+	require.Equal(t, "001788FFFE0A0B0C", bridgeId)
+	require.Equal(t, testServer.URL+"/description.xml", location)
+	description, err := fetchSSDPDescription(&http.Client{}, location)
+	require.NoError(t, err)
+	require.Equal(t, "BSB002", description.Device.ModelNumber)
+}
+
+func countMeasurement(a *testutil.Accumulator, measurement string) int {
+	count := 0
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == measurement {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGatherZoneMembership(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	plugin.ZoneMembership = "tag"
+	require.NoError(t, a.GatherError(plugin.Gather))
+	lightZones := make(map[string]string)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_light" {
+			lightZones[metric.Tags()["huebridge_device"]] = metric.Tags()["huebridge_zone"]
+		}
+		if metric.Name() == "huebridge_connectivity" && metric.Tags()["huebridge_device"] == "Lamp 2" {
+			require.Equal(t, "Downstairs", metric.Tags()["huebridge_zone"])
+		}
+	}
+	require.Equal(t, map[string]string{"Lamp 2": "Downstairs", "Lamp 4": "Downstairs", "Lamp 6": "", "Lamp 8": "", "Lamp 10": ""}, lightZones)
+	a.ClearMetrics()
+	plugin.ZoneMembership = "series"
+	require.NoError(t, a.GatherError(plugin.Gather))
+	lightCount := 0
+	zoneLightCount := 0
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_light" {
+			lightCount++
+			if metric.HasTag("huebridge_zone") {
+				zoneLightCount++
+			}
+		}
+	}
+	require.Equal(t, 7, lightCount)
+	require.Equal(t, 2, zoneLightCount)
+	plugin.ZoneMembership = "invalid"
+	require.Error(t, plugin.Init())
+}
+
+func TestGatherScenes(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_scene", map[string]interface{}{
+		"active": 1,
+		"status": "static",
+	}, map[string]string{
+		"huebridge_url":        testServer.URL,
+		"huebridge_id":         "001788fffe0a0b0c",
+		"huebridge_room":       "Flur",
+		"huebridge_scene":      "Relax",
+		"huebridge_scene_type": "scene",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_scene", map[string]interface{}{
+		"active": 0,
+		"status": "inactive",
+	}, map[string]string{
+		"huebridge_url":        testServer.URL,
+		"huebridge_id":         "001788fffe0a0b0c",
+		"huebridge_room":       "Flur",
+		"huebridge_scene":      "Concentrate",
+		"huebridge_scene_type": "scene",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_scene", map[string]interface{}{
+		"active": 1,
+		"status": "active",
+	}, map[string]string{
+		"huebridge_url":        testServer.URL,
+		"huebridge_id":         "001788fffe0a0b0c",
+		"huebridge_zone":       "Downstairs",
+		"huebridge_scene":      "Natural light",
+		"huebridge_scene_type": "smart_scene",
+	})
+}
+
+func TestGatherAutomations(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_automation", map[string]interface{}{
+		"enabled":         1,
+		"status":          "running",
+		"last_error":      "",
+		"script_category": "automation",
+	}, map[string]string{
+		"huebridge_url":               testServer.URL,
+		"huebridge_id":                "001788fffe0a0b0c",
+		"huebridge_automation":        "Wake up",
+		"huebridge_automation_script": "Wake up",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_automation", map[string]interface{}{
+		"enabled":         1,
+		"status":          "errored",
+		"last_error":      "missing_dependee",
+		"script_category": "automation",
+	}, map[string]string{
+		"huebridge_url":               testServer.URL,
+		"huebridge_id":                "001788fffe0a0b0c",
+		"huebridge_automation":        "Night path",
+		"huebridge_automation_script": "Timers",
+	})
+}
+
+func TestGatherMotions(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	// security_area_motion is not served and must be skipped silently
+	require.Empty(t, a.Errors)
+	motionSources := make(map[string]int)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_motion" {
+			motionSources[metric.Tags()["huebridge_motion_source"]]++
+		}
+	}
+	require.Equal(t, map[string]int{"motion": 1, "camera_motion": 1, "convenience_area_motion": 2}, motionSources)
+	// the motion areas are reported as separate series identified by the area name
+	a.AssertContainsTaggedFields(t, "huebridge_motion", map[string]interface{}{
+		"motion":          1,
+		"sensitivity":     3,
+		"sensitivity_max": 4,
+	}, map[string]string{
+		"huebridge_url":           testServer.URL,
+		"huebridge_id":            "001788fffe0a0b0c",
+		"huebridge_room":          "<unassigned>",
+		"huebridge_device":        "<undefined>",
+		"huebridge_motion_source": "convenience_area_motion",
+		"huebridge_motion_area":   "Living room",
+	})
+	a.AssertContainsTaggedFields(t, "huebridge_motion", map[string]interface{}{
+		"motion":          0,
+		"sensitivity":     2,
+		"sensitivity_max": 4,
+	}, map[string]string{
+		"huebridge_url":           testServer.URL,
+		"huebridge_id":            "001788fffe0a0b0c",
+		"huebridge_room":          "<unassigned>",
+		"huebridge_device":        "<undefined>",
+		"huebridge_motion_source": "convenience_area_motion",
+		"huebridge_motion_area":   "Kitchen",
+	})
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_motion" && metric.Tags()["huebridge_motion_source"] != "convenience_area_motion" {
+			require.NotContains(t, metric.Tags(), "huebridge_motion_area")
+		}
+	}
+}
+
+func TestGatherPresence(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_presence", map[string]interface{}{
+		"geolocation_configured": 1,
+		"geofence_clients":       2,
+		"day_type":               "normal_day",
+		"sunset_time":            "18:57:00",
+	}, map[string]string{
+		"huebridge_url": testServer.URL,
+		"huebridge_id":  "001788fffe0a0b0c",
+	})
+}
+
+func TestGatherUnreachableLights(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	plugin.UnreachableLights = "tag"
+	require.NoError(t, a.GatherError(plugin.Gather))
+	reachableLights := make(map[string]string)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_light" {
+			reachableLights[metric.Tags()["huebridge_device"]] = metric.Tags()["huebridge_reachable"]
+		}
+	}
+	require.Equal(t, map[string]string{"Lamp 2": "true", "Lamp 4": "true", "Lamp 6": "true", "Lamp 8": "true", "Lamp 10": "false"}, reachableLights)
+	a.ClearMetrics()
+	plugin.UnreachableLights = "skip"
+	require.NoError(t, a.GatherError(plugin.Gather))
+	lightCount := 0
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_light" {
+			require.NotEqual(t, "Lamp 10", metric.Tags()["huebridge_device"])
+			lightCount++
+		}
+	}
+	require.Equal(t, 4, lightCount)
+	plugin.UnreachableLights = "invalid"
+	require.Error(t, plugin.Init())
+}
+
+func TestGatherEntertainment(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	plugin.StreamingLights = "tag"
+	require.NoError(t, a.GatherError(plugin.Gather))
+	a.AssertContainsTaggedFields(t, "huebridge_entertainment", map[string]interface{}{
+		"active":             1,
+		"status":             "active",
+		"configuration_type": "screen",
+		"channels":           2,
+		"lights":             2,
+		"streamer_id":        "0a1b2c3d-4e5f-4a6b-7c8d-9e0f1a2b3c4d",
+		"streamer_type":      "auth_v1",
+	}, map[string]string{
+		"huebridge_url":           testServer.URL,
+		"huebridge_id":            "001788fffe0a0b0c",
+		"huebridge_entertainment": "TV area",
+	})
+	streamingLights := make(map[string]string)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_light" {
+			streamingLights[metric.Tags()["huebridge_device"]] = metric.Tags()["huebridge_streaming"]
+		}
+	}
+	require.Equal(t, map[string]string{"Lamp 2": "false", "Lamp 4": "false", "Lamp 6": "true", "Lamp 8": "true", "Lamp 10": "false"}, streamingLights)
+	a.ClearMetrics()
+	plugin.StreamingLights = "skip"
+	require.NoError(t, a.GatherError(plugin.Gather))
+	lightCount := 0
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_light" {
+			lightCount++
+		}
+	}
+	require.Equal(t, 3, lightCount)
+	plugin.StreamingLights = "invalid"
+	require.Error(t, plugin.Init())
+}
+
+func TestEvalEntertainmentStreamer(t *testing.T) {
+	var devices devicesList
+	require.NoError(t, json.Unmarshal([]byte(testResourceDevice), &devices))
+	plugin := NewHueBridge()
+
+	var a testutil.Accumulator
+
+	// a streaming device is resolved to it's name
+	plugin.evalEntertainmentConfigurations(&a, "https://127.0.0.1", &entertainmentConfigurationsStatus{Data: []entertainmentConfigurationData{{
+		Id:             "4a5b6c7d-8e9f-4a0b-9c1d-2e3f4a5b6c7d",
+		Status:         "active",
+		ActiveStreamer: &resourceLink{Rid: "5c8131ae-c187-4408-98d3-c362c5f777a3", Rtype: "device"},
+	}}}, &devices)
+	streamer, _ := a.GetTelegrafMetrics()[0].GetField("streamer")
+	require.Equal(t, "Lamp 4", streamer)
+	streamerId, _ := a.GetTelegrafMetrics()[0].GetField("streamer_id")
+	require.Equal(t, "5c8131ae-c187-4408-98d3-c362c5f777a3", streamerId)
+}
+
+func TestXYToRGBHex(t *testing.T) {
+	require.Equal(t, "#ffffff", xyToRGBHex(0.3127, 0.3290, 100.0))
+	require.Equal(t, "#000000", xyToRGBHex(0.3127, 0.3290, 0.0))
+	require.Equal(t, "#000000", xyToRGBHex(0.0, 0.0, 100.0))
+}
+
+func TestTrackState(t *testing.T) {
+	plugin := NewHueBridge()
+	require.Equal(t, time.Duration(0), plugin.trackState("update1", "update_pending"))
+	time.Sleep(10 * time.Millisecond)
+	require.GreaterOrEqual(t, plugin.trackState("update1", "update_pending"), 10*time.Millisecond)
+	require.Equal(t, time.Duration(0), plugin.trackState("update2", "update_pending"))
+	require.Equal(t, time.Duration(0), plugin.trackState("update1", "ready_to_install"))
+}
+
+func TestCountReport(t *testing.T) {
+	plugin := NewHueBridge()
+	require.Equal(t, 0, plugin.countReport("button1", "2024-01-23T10:00:00.000Z"))
+	require.Equal(t, 0, plugin.countReport("button1", "2024-01-23T10:00:00.000Z"))
+	require.Equal(t, 1, plugin.countReport("button1", "2024-01-23T10:00:01.000Z"))
+	require.Equal(t, 0, plugin.countReport("button2", "2024-01-23T10:00:01.000Z"))
+	require.Equal(t, 2, plugin.countReport("button1", "2024-01-23T10:00:02.000Z"))
+}
+
+func TestGather2(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "invalid_applicationkey"}}
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.Error(t, a.GatherError(plugin.Gather))
+}
+
+func TestEventStream(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.EventStream = true
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, plugin.Start(&a))
+	defer plugin.Stop()
+	require.Eventually(t, func() bool {
+		return len(motionStates(&a)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	// the backfilled state followed by the motion event
+	require.Equal(t, []int64{0, 1}, motionStates(&a))
+	// the bridge stats are reported on every gather
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Empty(t, a.Errors)
+	require.Equal(t, 1, countMeasurement(&a, "huebridge_bridge"))
+	require.Equal(t, 1, len(a.GetTelegrafMetrics()))
+	metric := a.GetTelegrafMetrics()[0]
+	require.Equal(t, "001788fffe0a0b0c", metric.Tags()["huebridge_id"])
+	modelId, _ := metric.GetField("model_id")
+	require.Equal(t, "BSB002", modelId)
+	requestErrors, _ := metric.GetField("request_errors")
+	require.Equal(t, int64(0), requestErrors)
+}
+
+func TestEventStreamReconnect(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, CloseEventStream: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	eventStreamReconnectDelay = 10 * time.Millisecond
+	defer func() { eventStreamReconnectDelay = 5 * time.Second }()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.EventStream = true
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, plugin.Start(&a))
+	defer plugin.Stop()
+	require.Eventually(t, func() bool {
+		return len(motionStates(&a)) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+	plugin.Stop()
+	require.Equal(t, int32(2), testServerHandler.eventStreamConnects.Load())
+	// every connect is followed by a backfill before the next event is delivered
+	require.Equal(t, []int64{0, 1, 0, 1}, motionStates(&a))
+	require.Equal(t, 2, countMeasurement(&a, "huebridge_bridge"))
+	require.Len(t, a.Errors, 1)
+	require.ErrorContains(t, a.Errors[0], "event stream closed by bridge")
+}
+
+func TestEventStreamBridgeMoved(t *testing.T) {
+	testServerHandler1 := &testServerHandler{Debug: true, CloseEventStream: true}
+	testServer1 := httptest.NewServer(testServerHandler1)
+	defer testServer1.Close()
+	testServerHandler2 := &testServerHandler{Debug: true}
+	testServer2 := httptest.NewServer(testServerHandler2)
+	defer testServer2.Close()
+	eventStreamReconnectDelay = 10 * time.Millisecond
+	defer func() { eventStreamReconnectDelay = 5 * time.Second }()
+	// the bridge moves to the 2nd server after the 1st discovery
+	discoveries := atomic.Int32{}
+	discoverBridges = func(timeout time.Duration) ([]DiscoveredBridge, error) {
+		if discoveries.Add(1) == 1 {
+			return []DiscoveredBridge{{BridgeId: "001788fffe0a0b0c", Address: "127.0.0.1", Model: "BSB002", Url: testServer1.URL}}, nil
+		}
+		return []DiscoveredBridge{{BridgeId: "001788fffe0a0b0c", Address: "127.0.0.1", Model: "BSB002", Url: testServer2.URL}}, nil
+	}
+	defer func() { discoverBridges = Discover }()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{"auto:001788fffe0a0b0c", "applicationkey"}}
+	plugin.EventStream = true
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler1.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, plugin.Start(&a))
+	defer plugin.Stop()
+	require.Eventually(t, func() bool {
+		return testServerHandler2.eventStreamConnects.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	testServer1.Close()
+
+	var gatherA testutil.Accumulator
+
+	// only the bridge's current url is reported
+	require.NoError(t, gatherA.GatherError(plugin.Gather))
+	require.Empty(t, gatherA.Errors)
+	bridgeUrls := make([]string, 0)
+	for _, metric := range gatherA.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_bridge" {
+			bridgeUrls = append(bridgeUrls, metric.Tags()["huebridge_url"])
+		}
+	}
+	require.Equal(t, []string{testServer2.URL}, bridgeUrls)
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	require.Equal(t, map[*BridgeConfig]string{plugin.bridges[0]: testServer2.URL}, plugin.activeBridges)
+	require.NotContains(t, plugin.bridgeIds, testServer1.URL)
+	require.NotContains(t, plugin.deviceInfoReported, testServer1.URL)
+}
+
+func TestEventStreamGroupedLights(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true, EventStream: testEventStreamGroupedLight}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.EventStream = true
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, plugin.Start(&a))
+	defer plugin.Stop()
+	groupedLightsOn := func() []int64 {
+		lightsOn := make([]int64, 0)
+		for _, metric := range a.GetTelegrafMetrics() {
+			if metric.Name() == "huebridge_grouped_light" && metric.Tags()["huebridge_room"] == "Flur" {
+				lightOn, _ := metric.GetField("lights_on")
+				lightsOn = append(lightsOn, lightOn.(int64))
+			}
+		}
+		return lightsOn
+	}
+	require.Eventually(t, func() bool {
+		return len(groupedLightsOn()) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	plugin.Stop()
+	require.Empty(t, a.Errors)
+	// the backfill followed by the grouped light events before and after a light has been switched off
+	require.Equal(t, []int64{3, 3, 2}, groupedLightsOn())
+	// the lights are fetched once for the backfill and once for the grouped light events
+	require.Equal(t, int32(2), testServerHandler.lightRequests.Load())
+}
+
+func TestEventStreamEmitChanges(t *testing.T) {
+	testServerHandler := &testServerHandler{Debug: true}
+	testServer := httptest.NewServer(testServerHandler)
+	defer testServer.Close()
+	plugin := NewHueBridge()
+	plugin.Bridges = [][]string{{testServer.URL, "applicationkey"}}
+	plugin.EventStream = true
+	plugin.Emit = "changes"
+	plugin.Log = createDummyLogger()
+	plugin.Debug = testServerHandler.Debug
+	require.NoError(t, plugin.Init())
+
+	var a testutil.Accumulator
+
+	require.NoError(t, plugin.Start(&a))
+	defer plugin.Stop()
+	require.Eventually(t, func() bool {
+		return len(motionStates(&a)) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	a.ClearMetrics()
+	// nothing has changed, only the bridge stats are emitted
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 1, len(a.GetTelegrafMetrics()))
+	require.True(t, a.HasMeasurement("huebridge_bridge"))
+	a.ClearMetrics()
+	// heartbeat interval elapsed, the last snapshot of every series is emitted again
+	plugin.stateLock.Lock()
+	for _, snapshot := range plugin.emittedSnapshots {
+		snapshot.emitted = snapshot.emitted.Add(-time.Duration(plugin.HeartbeatInterval) * time.Second)
+	}
+	plugin.stateLock.Unlock()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.True(t, a.HasMeasurement("huebridge_light"))
+	require.True(t, a.HasMeasurement("huebridge_temperature"))
+	require.Equal(t, []int64{1}, motionStates(&a))
+	for _, metric := range a.GetTelegrafMetrics() {
+		require.Equal(t, "001788fffe0a0b0c", metric.Tags()["huebridge_id"])
+	}
+	a.ClearMetrics()
+	require.NoError(t, a.GatherError(plugin.Gather))
+	require.Equal(t, 1, len(a.GetTelegrafMetrics()))
+}
+
+// motionStates collects the motion values reported by the test bridge's motion sensor
+func motionStates(a *testutil.Accumulator) []int64 {
+	states := make([]int64, 0)
+	for _, metric := range a.GetTelegrafMetrics() {
+		if metric.Name() == "huebridge_motion" && metric.Tags()["huebridge_motion_source"] == "motion" {
+			motion, _ := metric.GetField("motion")
+			states = append(states, motion.(int64))
+		}
+	}
+	return states
+}
+
+func createDummyLogger() *dummyLogger {
+	log.SetOutput(os.Stderr)
+	return &dummyLogger{}
+}
+
+type dummyLogger struct{}
+
+func (l *dummyLogger) Errorf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
+
+func (l *dummyLogger) Error(args ...interface{}) {
+	log.Print(args...)
+}
+
+func (l *dummyLogger) Debugf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
+
+func (l *dummyLogger) Debug(args ...interface{}) {
+	log.Print(args...)
+}
+
+func (l *dummyLogger) Warnf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
+
+func (l *dummyLogger) Warn(args ...interface{}) {
+	log.Print(args...)
+}
+
+func (l *dummyLogger) Infof(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
+
+func (l *dummyLogger) Info(args ...interface{}) {
+	log.Print(args...)
+}
+
+type testServerHandler struct {
+	Debug               bool
+	Delay               time.Duration
+	CloseEventStream    bool
+	EventStream         string
+	UnavailablePath     string
+	activeRequests      atomic.Int32
+	maxActiveRequests   atomic.Int32
+	pairRequests        atomic.Int32
+	eventStreamConnects atomic.Int32
+	lightRequests       atomic.Int32
+}
+
+func (tsh *testServerHandler) ServeHTTP(out http.ResponseWriter, request *http.Request) {
+	requestURL := request.URL.String()
+	if tsh.Debug {
+		log.Printf("test: request URL: %s", requestURL)
+	}
+	activeRequests := tsh.activeRequests.Add(1)
+	defer tsh.activeRequests.Add(-1)
+	for {
+		maxActiveRequests := tsh.maxActiveRequests.Load()
+		if activeRequests <= maxActiveRequests || tsh.maxActiveRequests.CompareAndSwap(maxActiveRequests, activeRequests) {
+			break
+		}
+	}
+	time.Sleep(tsh.Delay)
+	if tsh.UnavailablePath != "" && strings.HasPrefix(requestURL, tsh.UnavailablePath) {
+		out.WriteHeader(http.StatusServiceUnavailable)
+	} else if requestURL == "/api/0/config" {
+		tsh.serveAPIConfig(out, request)
+	} else if requestURL == "/description.xml" {
+		tsh.serveDescription(out, request)
+	} else if requestURL == "/api" && request.Method == http.MethodPost {
+		tsh.servePair(out, request)
+	} else if request.Header.Get("hue-application-key") != "applicationkey" {
+		out.WriteHeader(http.StatusUnauthorized)
+	} else if requestURL == "/clip/v2/resource" {
+		tsh.serveResourceSnapshot(out, request)
+	} else if requestURL == "/clip/v2/resource/bridge" {
+		tsh.serveResourceBridge(out, request)
+	} else if requestURL == "/clip/v2/resource/zigbee_connectivity/8e2f4a6b-1c3d-4e5f-a6b7-c8d9e0f1a2b3" {
+		tsh.serveResourceBridgeZigbeeConnectivity(out, request)
+	} else if requestURL == "/clip/v2/resource/light" {
+		tsh.lightRequests.Add(1)
+		tsh.serveResourceLight(out, request)
+	} else if requestURL == "/clip/v2/resource/light/ad90a702-a167-4a7c-8bf0-c87cc5938855" {
+		tsh.serveResourceItem(out, request, testResourceLight, "ad90a702-a167-4a7c-8bf0-c87cc5938855")
+	} else if requestURL == "/clip/v2/resource/temperature" {
+		tsh.serveResourceTemperature(out, request)
+	} else if requestURL == "/clip/v2/resource/light_level" {
+		tsh.serveResourceLightLevel(out, request)
+	} else if requestURL == "/clip/v2/resource/motion" || requestURL == "/clip/v2/resource/motion/4a50cccd-b1d7-447e-bd94-3e73b2e6097a" {
+		tsh.serveResourceMotion(out, request)
+	} else if requestURL == "/clip/v2/resource/device_power" {
+		tsh.serveResourceDevicePower(out, request)
+	} else if requestURL == "/clip/v2/resource/button" {
+		tsh.serveResourceButton(out, request)
+	} else if requestURL == "/clip/v2/resource/relative_rotary" {
+		tsh.serveResourceRelativeRotary(out, request)
+	} else if requestURL == "/clip/v2/resource/contact" {
+		tsh.serveResourceContact(out, request)
+	} else if requestURL == "/clip/v2/resource/tamper" {
+		tsh.serveResourceTamper(out, request)
+	} else if requestURL == "/clip/v2/resource/zigbee_connectivity" {
+		tsh.serveResourceZigbeeConnectivity(out, request)
+	} else if requestURL == "/clip/v2/resource/zgp_connectivity" {
+		tsh.serveResourceZgpConnectivity(out, request)
+	} else if requestURL == "/clip/v2/resource/grouped_light" {
+		tsh.serveResourceGroupedLight(out, request)
+	} else if requestURL == "/clip/v2/resource/grouped_light/1b6f6e1c-3c1b-4b8a-9d0e-5f4a3b2c1d0e" {
+		tsh.serveResourceItem(out, request, testResourceGroupedLight, "1b6f6e1c-3c1b-4b8a-9d0e-5f4a3b2c1d0e")
+	} else if requestURL == "/clip/v2/resource/device_software_update" {
+		tsh.serveResourceDeviceSoftwareUpdate(out, request)
+	} else if requestURL == "/clip/v2/resource/scene" {
+		tsh.serveResourceScene(out, request)
+	} else if requestURL == "/clip/v2/resource/smart_scene" {
+		tsh.serveResourceSmartScene(out, request)
+	} else if requestURL == "/clip/v2/resource/behavior_instance" {
+		tsh.serveResourceBehaviorInstance(out, request)
+	} else if requestURL == "/clip/v2/resource/behavior_script" {
+		tsh.serveResourceBehaviorScript(out, request)
+	} else if requestURL == "/clip/v2/resource/entertainment_configuration" {
+		tsh.serveResourceEntertainmentConfiguration(out, request)
+	} else if requestURL == "/clip/v2/resource/geolocation" {
+		tsh.serveResourceGeolocation(out, request)
+	} else if requestURL == "/clip/v2/resource/geofence_client" {
+		tsh.serveResourceGeofenceClient(out, request)
+	} else if requestURL == "/clip/v2/resource/device" {
+		tsh.serveResourceDevice(out, request)
+	} else if requestURL == "/clip/v2/resource/room" {
+		tsh.serveResourceRoom(out, request)
+	} else if requestURL == "/clip/v2/resource/zone" {
+		tsh.serveResourceZone(out, request)
+	} else if requestURL == "/eventstream/clip/v2" {
+		tsh.serveEventStream(out, request)
+	} else if requestURL == "/clip/v2/resource/camera_motion" {
+		tsh.serveResourceCameraMotion(out, request)
+	} else if requestURL == "/clip/v2/resource/grouped_motion" {
+		tsh.serveResourceGroupedMotion(out, request)
+	} else if requestURL == "/clip/v2/resource/grouped_light_level" {
+		tsh.serveResourceGroupedLightLevel(out, request)
+	} else if requestURL == "/clip/v2/resource/convenience_area_motion" {
+		tsh.serveResourceConvenienceAreaMotion(out, request)
+	} else if requestURL == "/clip/v2/resource/motion_area_configuration" {
+		tsh.serveResourceMotionAreaConfiguration(out, request)
+	} else {
+		http.NotFound(out, request)
+	}
+}
+
+const testPairLinkButtonNotPressed = `[{"error":{"type":101,"address":"","description":"link button not pressed"}}]`
+const testPairSuccess = `[{"success":{"username":"applicationkey","clientkey":"0123456789ABCDEF0123456789ABCDEF"}}]`
+
+func (tsh *testServerHandler) servePair(out http.ResponseWriter, request *http.Request) {
+	// the link button is pressed during the 3rd request
+	if tsh.pairRequests.Add(1) < 3 {
+		tsh.writeJSON(out, testPairLinkButtonNotPressed)
+	} else {
+		tsh.writeJSON(out, testPairSuccess)
+	}
+}
+
+const testDescription = `<?xml version="1.0" encoding="UTF-8" ?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+<specVersion><major>1</major><minor>0</minor></specVersion>
+<device>
+<deviceType>urn:schemas-upnp-org:device:Basic:1</deviceType>
+<friendlyName>huebridge1</friendlyName>
+<manufacturer>Signify</manufacturer>
+<modelName>Philips hue bridge 2015</modelName>
+<modelNumber>BSB002</modelNumber>
+<serialNumber>0017880a0b0c</serialNumber>
+</device>
+</root>
+`
+
+func (tsh *testServerHandler) serveDescription(out http.ResponseWriter, request *http.Request) {
+	out.Header().Add("Content-Type", "text/xml")
+	_, _ = out.Write([]byte(testDescription))
+}
+
+const testAPIConfig = `
+{
+	"name":"huebridge1",
+	"datastoreversion":"163",
+	"swversion":"1962154010",
+	"apiversion":"1.62.0",
+	"mac":"00:17:88:0a:0b:0c",
+	"bridgeid":"001788FFFE0A0B0C",
+	"factorynew":false,
+	"replacesbridgeid":null,
+	"modelid":"BSB002",
+	"starterkitid":""
+}
+`
+
+func (tsh *testServerHandler) serveAPIConfig(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testAPIConfig)
+}
+
+const testResourceBridge = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"bridge_id":"001788fffe0a0b0c",
+		"id":"c2d1e0f9-a8b7-4c6d-9e5f-4a3b2c1d0e9f",
+		"id_v1":"",
+		"owner":{
+		  "rid":"b090d566-2fd5-4fac-b12c-b23e4d82d349",
+		  "rtype":"device"
+		},
+		"time_zone":{
+		  "time_zone":"Europe/Berlin"
+		},
+		"type":"bridge"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceBridge(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceBridge)
+}
+
+const testResourceBridgeZigbeeConnectivity = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"channel":{
+		  "status":"set",
+		  "value":"channel_25"
+		},
+		"extended_pan_id":"1a2b3c4d5e6f7a8b",
+		"id":"8e2f4a6b-1c3d-4e5f-a6b7-c8d9e0f1a2b3",
+		"id_v1":"",
+		"mac_address":"00:17:88:01:0a:0b:0c:0e",
+		"owner":{
+		  "rid":"b090d566-2fd5-4fac-b12c-b23e4d82d349",
+		  "rtype":"device"
+		},
+		"status":"connected",
+		"type":"zigbee_connectivity"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceBridgeZigbeeConnectivity(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceBridgeZigbeeConnectivity)
+}
+
+const testResourceLight = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"id":"519df633-bcad-489e-a490-353b6bfaf2bf",
+		"id_v1":"/lights/4",
+		"metadata":{
+		  "archetype":"candle_bulb",
+		  "name":"Lamp 1"
+		},
+		"mode":"normal",
+		"on":{
+		  "on":false
+		},
+		"owner":{
+		  "rid":"86b46c71-ba47-4deb-99e2-6e5ae5815a8d",
+		  "rtype":"device"
+		},
+		"type":"light"
+	  },
+	  {
+		"id":"ad90a702-a167-4a7c-8bf0-c87cc5938855",
+		"id_v1":"/lights/8",
+		"metadata":{
+		  "archetype":"sultan_bulb",
+		  "name":"Lamp 2"
+		},
+		"mode":"normal",
+		"on":{
+		  "on":true
+		},
+		"dimming":{
+		  "brightness":50.2,
+		  "min_dim_level":0.2
+		},
+		"color_temperature":{
+		  "mirek":370,
+		  "mirek_valid":true,
+		  "mirek_schema":{
+			"mirek_maximum":500,
+			"mirek_minimum":153
+		  }
+		},
+		"color":{
+		  "xy":{
+			"x":0.4573,
+			"y":0.41
+		  },
+		  "gamut_type":"C"
+		},
+		"dynamics":{
+		  "speed":0.0,
+		  "speed_valid":false,
+		  "status":"none"
+		},
+		"effects":{
+		  "status":"no_effect"
+		},
+		"owner":{
+		  "rid":"5c8131ae-c187-4408-98d3-c362c5f777a3",
+		  "rtype":"device"
+		},
+		"type":"light"
+	  },
+	  {
+		"id":"be27e7a9-acd4-42da-a1e1-ceac9e279681",
+		"id_v1":"/lights/2",
+		"metadata":{
+		  "archetype":"classic_bulb",
+		  "name":"Lamp 3"
+		},
+		"mode":"normal",
+		"on":{
+		  "on":true
+		},
+		"owner":{
+		  "rid":"b9e3eca4-c718-4445-9833-aa5f349c8219",
+		  "rtype":"device"
+		},
+		"type":"light"
+	  },
+	  {
+		"id":"22e46126-e936-4784-a628-7e8f084e9019",
+		"id_v1":"/lights/9",
+		"metadata":{
+		  "archetype":"sultan_bulb",
+		  "name":"Lamp 4"
+		},
+		"mode":"normal",
+		"on":{
+		  "on":true
+		},
+		"owner":{
+		  "rid":"549995d5-a934-4469-ba27-6d1391633ff8",
+		  "rtype":"device"
+		},
+		"type":"light"
+	  },
+	  {
+		"id":"a3c5f066-f598-41eb-bae2-33d3aa34000c",
+		"id_v1":"/lights/7",
+		"metadata":{
+		  "archetype":"candle_bulb",
+		  "name":"Lamp 5"
+		},
+		"mode":"normal",
+		"on":{
+		  "on":false
+		},
+		"owner":{
+		  "rid":"4e16129d-464c-48fc-9193-6264e463e3df",
+		  "rtype":"device"
+		},
+		"type":"light"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceLight(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceLight)
+}
+
+const testResourceTemperature = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"enabled":true,
+		"id":"5606a921-4315-4d66-867a-552718df8cae",
+		"id_v1":"/sensors/3",
+		"owner":{
+		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
+		  "rtype":"device"
+		},
+		"temperature":{
+		  "temperature":20.45,
+		  "temperature_valid":true
+		},
+		"type":"temperature"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceTemperature(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceTemperature)
+}
+
+const testResourceLightLevel = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"enabled":true,
+		"id":"b612b85c-1231-4aed-ac03-98250efdbb6c",
+		"id_v1":"/sensors/5",
+		"light":{
+		  "light_level":1563,
+		  "light_level_valid":true
+		},
+		"owner":{
+		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
+		  "rtype":"device"
+		},
+		"type":"light_level"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceLightLevel(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceLightLevel)
+}
+
+const testResourceMotion = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"enabled":true,
+		"id":"4a50cccd-b1d7-447e-bd94-3e73b2e6097a",
+		"id_v1":"/sensors/4",
+		"motion":{
+		  "motion":false,
+		  "motion_valid":true
+		},
+		"owner":{
+		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
+		  "rtype":"device"
+		},
+		"type":"motion"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceMotion(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceMotion)
+}
+
+const testResourceCameraMotion = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"enabled":true,
+		"id":"8e9f0a1b-2c3d-4e4f-9a5b-6c7d8e9f0a1b",
+		"motion":{
+		  "motion":false,
+		  "motion_valid":true
+		},
+		"owner":{
+		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
+		  "rtype":"device"
+		},
+		"sensitivity":{
+		  "sensitivity":2,
+		  "sensitivity_max":4,
+		  "status":"set"
+		},
+		"type":"camera_motion"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceCameraMotion(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceCameraMotion)
+}
+
+const testResourceConvenienceAreaMotion = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"enabled":true,
+		"id":"9f0a1b2c-3d4e-4f5a-8b6c-7d8e9f0a1b2c",
+		"motion":{
+		  "motion":true,
+		  "motion_valid":true
+		},
+		"owner":{
+		  "rid":"0a1b2c3d-4e5f-4a6b-9c7d-8e9f0a1b2c3d",
+		  "rtype":"motion_area_configuration"
+		},
+		"sensitivity":{
+		  "sensitivity":3,
+		  "sensitivity_max":4,
+		  "status":"set"
+		},
+		"type":"convenience_area_motion"
+	  },
+	  {
+		"enabled":true,
+		"id":"3c4d5e6f-7a8b-4c9d-8e0f-1a2b3c4d5e6f",
+		"motion":{
+		  "motion":false,
+		  "motion_valid":true
+		},
+		"owner":{
+		  "rid":"1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d5f",
+		  "rtype":"motion_area_configuration"
+		},
+		"sensitivity":{
+		  "sensitivity":2,
+		  "sensitivity_max":4,
+		  "status":"set"
+		},
+		"type":"convenience_area_motion"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceConvenienceAreaMotion(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceConvenienceAreaMotion)
+}
+
+const testResourceMotionAreaConfiguration = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"enabled":true,
+		"id":"0a1b2c3d-4e5f-4a6b-9c7d-8e9f0a1b2c3d",
+		"metadata":{
+		  "name":"Living room"
+		},
+		"type":"motion_area_configuration"
+	  },
+	  {
+		"enabled":true,
+		"id":"1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d5f",
+		"metadata":{
+		  "name":"Kitchen"
+		},
+		"type":"motion_area_configuration"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceMotionAreaConfiguration(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceMotionAreaConfiguration)
+}
+
+const testResourceGroupedMotion = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"enabled":true,
+		"id":"1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d5e",
+		"motion":{
+		  "motion_report":{
+			"changed":"2024-01-23T10:00:00.000Z",
+			"motion":true
+		  }
+		},
+		"owner":{
+		  "rid":"e8006e01-92a3-4bc7-9102-a768259187b0",
+		  "rtype":"room"
+		},
+		"type":"grouped_motion"
+	  },
+	  {
+		"enabled":true,
+		"id":"2c3d4e5f-6a7b-4c8d-9e0f-1a2b3c4d5e6f",
+		"motion":{
+		  "motion_report":{
+			"changed":"2024-01-23T10:00:00.000Z",
+			"motion":true
+		  }
+		},
+		"owner":{
+		  "rid":"f1e2d3c4-b5a6-4978-8695-a4b3c2d1e0f9",
+		  "rtype":"bridge_home"
+		},
+		"type":"grouped_motion"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceGroupedMotion(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceGroupedMotion)
+}
+
+const testResourceGroupedLightLevel = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"enabled":true,
+		"id":"3d4e5f6a-7b8c-4d9e-8f0a-2b3c4d5e6f7a",
+		"light":{
+		  "light_level_report":{
+			"changed":"2024-01-23T10:00:00.000Z",
+			"light_level":18000
+		  }
+		},
+		"owner":{
+		  "rid":"e8006e01-92a3-4bc7-9102-a768259187b0",
+		  "rtype":"room"
+		},
+		"type":"grouped_light_level"
+	  },
+	  {
+		"enabled":true,
+		"id":"4e5f6a7b-8c9d-4e0f-9a1b-3c4d5e6f7a8b",
+		"light":{
+		  "light_level_report":{
+			"changed":"2024-01-23T10:00:00.000Z",
+			"light_level":17000
+		  }
+		},
+		"owner":{
+		  "rid":"f1e2d3c4-b5a6-4978-8695-a4b3c2d1e0f9",
+		  "rtype":"bridge_home"
+		},
+		"type":"grouped_light_level"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceGroupedLightLevel(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceGroupedLightLevel)
+}
+
+const testResourceDevicePower = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"id":"52d23eb6-c9b5-4641-b873-3d441888c34b",
+		"id_v1":"/sensors/4",
+		"owner":{
+		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
+		  "rtype":"device"
+		},
+		"power_state":{
+		  "battery_level":100,
+		  "battery_state":"normal"
+		},
+		"type":"device_power"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceDevicePower(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceDevicePower)
+}
+
+const testResourceButton = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"button":{
+		  "button_report":{
+			"event":"short_release",
+			"updated":"2024-01-23T10:00:00.123Z"
+		  },
+		  "event_values":[
+			"initial_press",
+			"repeat",
+			"short_release",
+			"long_release",
+			"long_press"
+		  ],
+		  "last_event":"short_release",
+		  "repeat_interval":800
+		},
+		"id":"0dc0e9cf-8a8b-4af5-8e67-aed7a2a3ba6a",
+		"id_v1":"/sensors/12",
+		"metadata":{
+		  "control_id":1
+		},
+		"owner":{
+		  "rid":"c64e4b4c-4a33-4d71-8c0b-5bd84a3b6d38",
+		  "rtype":"device"
+		},
+		"type":"button"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceButton(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceButton)
+}
+
+const testResourceRelativeRotary = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"id":"e1cfc5a8-3a87-4e0e-a6bb-f2d3f1b1e0a0",
+		"id_v1":"/sensors/12",
+		"owner":{
+		  "rid":"c64e4b4c-4a33-4d71-8c0b-5bd84a3b6d38",
+		  "rtype":"device"
+		},
+		"relative_rotary":{
+		  "last_event":{
+			"action":"start",
+			"rotation":{
+			  "direction":"clock_wise",
+			  "duration":400,
+			  "steps":30
+			}
+		  },
+		  "rotary_report":{
+			"action":"start",
+			"rotation":{
+			  "direction":"clock_wise",
+			  "duration":400,
+			  "steps":30
+			},
+			"updated":"2024-01-23T10:00:01.456Z"
+		  }
+		},
+		"type":"relative_rotary"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceRelativeRotary(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceRelativeRotary)
+}
+
+const testResourceContact = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"contact_report":{
+		  "changed":"2024-01-23T09:45:12.345Z",
+		  "state":"no_contact"
+		},
+		"enabled":true,
+		"id":"7a1a3a4f-6b0e-4f5e-a0a5-5f4c3a2b1c0d",
+		"owner":{
+		  "rid":"3f0b6b9e-2d5c-4c4b-8f6a-1e2d3c4b5a69",
+		  "rtype":"device"
+		},
+		"type":"contact"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceContact(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceContact)
+}
+
+const testResourceTamper = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"id":"a0c9e8d7-1b2a-4c3d-9e8f-7a6b5c4d3e2f",
+		"owner":{
+		  "rid":"3f0b6b9e-2d5c-4c4b-8f6a-1e2d3c4b5a69",
+		  "rtype":"device"
+		},
+		"tamper_reports":[
+		  {
+			"changed":"2024-01-22T18:30:00.000Z",
+			"source":"battery_door",
+			"state":"not_tampered"
+		  }
+		],
+		"type":"tamper"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceTamper(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceTamper)
+}
+
+const testResourceZigbeeConnectivity = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"channel":{
+		  "status":"set",
+		  "value":"channel_25"
+		},
+		"extended_pan_id":"1a2b3c4d5e6f7a8b",
+		"id":"f4e6c2b9-5a1d-4e8f-9c3b-2d7a6e5f4c3b",
+		"id_v1":"/lights/4",
+		"mac_address":"00:17:88:01:0a:0b:0c:0d",
+		"owner":{
+		  "rid":"86b46c71-ba47-4deb-99e2-6e5ae5815a8d",
+		  "rtype":"device"
+		},
+		"status":"connected",
+		"type":"zigbee_connectivity"
+	  },
+	  {
+		"channel":{
+		  "status":"set",
+		  "value":"channel_25"
+		},
+		"extended_pan_id":"1a2b3c4d5e6f7a8b",
+		"id":"0b9c8d7e-6f5a-4b3c-2d1e-0f9a8b7c6d5e",
+		"id_v1":"/lights/7",
+		"mac_address":"00:17:88:01:1a:1b:1c:1d",
+		"owner":{
+		  "rid":"4e16129d-464c-48fc-9193-6264e463e3df",
+		  "rtype":"device"
+		},
+		"status":"connectivity_issue",
+		"type":"zigbee_connectivity"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceZigbeeConnectivity(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceZigbeeConnectivity)
+}
+
+const testResourceZgpConnectivity = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"id":"5d4c3b2a-1f0e-4d9c-8b7a-6f5e4d3c2b1a",
+		"id_v1":"/sensors/20",
+		"owner":{
+		  "rid":"c64e4b4c-4a33-4d71-8c0b-5bd84a3b6d38",
+		  "rtype":"device"
+		},
+		"source_id":"00:00:00:00:01:6d:1a:2b",
+		"status":"connected",
+		"type":"zgp_connectivity"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceZgpConnectivity(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceZgpConnectivity)
+}
+
+const testResourceGroupedLight = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"dimming":{
+		  "brightness":75.5
+		},
+		"id":"1b6f6e1c-3c1b-4b8a-9d0e-5f4a3b2c1d0e",
+		"id_v1":"/groups/3",
+		"on":{
+		  "on":true
+		},
+		"owner":{
+		  "rid":"e8006e01-92a3-4bc7-9102-a768259187b0",
+		  "rtype":"room"
+		},
+		"type":"grouped_light"
+	  },
+	  {
+		"dimming":{
+		  "brightness":50.2
+		},
+		"id":"2c7a7f2d-4d2c-4c9b-8e1f-6a5b4c3d2e1f",
+		"id_v1":"/groups/6",
+		"on":{
+		  "on":true
+		},
+		"owner":{
+		  "rid":"9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d",
+		  "rtype":"zone"
+		},
+		"type":"grouped_light"
+	  },
+	  {
+		"id":"3d8b8a3e-5e3d-4dac-9f2a-7b6c5d4e3f2a",
+		"id_v1":"/groups/0",
+		"on":{
+		  "on":true
+		},
+		"owner":{
+		  "rid":"f1e2d3c4-b5a6-4978-8695-a4b3c2d1e0f9",
+		  "rtype":"bridge_home"
+		},
+		"type":"grouped_light"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceGroupedLight(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceGroupedLight)
+}
+
+const testResourceDeviceSoftwareUpdate = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"id":"6e5d4c3b-2a19-4f8e-b7d6-c5b4a3928170",
+		"owner":{
+		  "rid":"5c8131ae-c187-4408-98d3-c362c5f777a3",
+		  "rtype":"device"
+		},
+		"problems":[
+		  
+		],
+		"state":"update_pending",
+		"type":"device_software_update"
+	  },
+	  {
+		"id":"7f6e5d4c-3b2a-4098-c8e7-d6c5b4a39281",
+		"owner":{
+		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
+		  "rtype":"device"
+		},
+		"problems":[
+		  "battery_too_low"
+		],
+		"state":"no_update",
+		"type":"device_software_update"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceDeviceSoftwareUpdate(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceDeviceSoftwareUpdate)
+}
+
+const testResourceScene = `
 {
 	"errors":[
 	  
 	],
 	"data":[
 	  {
-		"id":"519df633-bcad-489e-a490-353b6bfaf2bf",
-		"id_v1":"/lights/4",
-		"metadata":{
-		  "archetype":"candle_bulb",
-		  "name":"Lamp 1"
+		"actions":[
+		  
+		],
+		"group":{
+		  "rid":"e8006e01-92a3-4bc7-9102-a768259187b0",
+		  "rtype":"room"
 		},
-		"mode":"normal",
-		"on":{
-		  "on":false
+		"id":"a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c5d",
+		"id_v1":"/scenes/abcdefgh",
+		"metadata":{
+		  "name":"Relax"
 		},
-		"owner":{
-		  "rid":"86b46c71-ba47-4deb-99e2-6e5ae5815a8d",
-		  "rtype":"device"
+		"speed":0.6,
+		"status":{
+		  "active":"static"
 		},
-		"type":"light"
+		"type":"scene"
 	  },
 	  {
-		"id":"ad90a702-a167-4a7c-8bf0-c87cc5938855",
-		"id_v1":"/lights/8",
-		"metadata":{
-		  "archetype":"sultan_bulb",
-		  "name":"Lamp 2"
+		"actions":[
+		  
+		],
+		"group":{
+		  "rid":"e8006e01-92a3-4bc7-9102-a768259187b0",
+		  "rtype":"room"
 		},
-		"mode":"normal",
-		"on":{
-		  "on":true
+		"id":"b2c3d4e5-f6a7-4b8c-9d0e-1f2a3b4c5d6e",
+		"id_v1":"/scenes/ijklmnop",
+		"metadata":{
+		  "name":"Concentrate"
 		},
-		"owner":{
-		  "rid":"5c8131ae-c187-4408-98d3-c362c5f777a3",
-		  "rtype":"device"
+		"speed":0.6,
+		"status":{
+		  "active":"inactive"
 		},
-		"type":"light"
-	  },
+		"type":"scene"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceScene(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceScene)
+}
+
+const testResourceSmartScene = `
+{
+	"errors":[
+	  
+	],
+	"data":[
 	  {
-		"id":"be27e7a9-acd4-42da-a1e1-ceac9e279681",
-		"id_v1":"/lights/2",
-		"metadata":{
-		  "archetype":"classic_bulb",
-		  "name":"Lamp 3"
-		},
-		"mode":"normal",
-		"on":{
-		  "on":true
+		"active_timeslot":{
+		  "timeslot_id":1,
+		  "weekday":"tuesday"
 		},
-		"owner":{
-		  "rid":"b9e3eca4-c718-4445-9833-aa5f349c8219",
-		  "rtype":"device"
+		"group":{
+		  "rid":"9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d",
+		  "rtype":"zone"
 		},
-		"type":"light"
-	  },
-	  {
-		"id":"22e46126-e936-4784-a628-7e8f084e9019",
-		"id_v1":"/lights/9",
+		"id":"c3d4e5f6-a7b8-4c9d-0e1f-2a3b4c5d6e7f",
 		"metadata":{
-		  "archetype":"sultan_bulb",
-		  "name":"Lamp 4"
+		  "name":"Natural light"
 		},
-		"mode":"normal",
-		"on":{
-		  "on":true
+		"state":"active",
+		"type":"smart_scene"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceSmartScene(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceSmartScene)
+}
+
+const testResourceBehaviorInstance = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"configuration":{
+		  
 		},
-		"owner":{
-		  "rid":"549995d5-a934-4469-ba27-6d1391633ff8",
-		  "rtype":"device"
+		"dependees":[
+		  
+		],
+		"enabled":true,
+		"id":"d4e5f6a7-b8c9-4d0e-1f2a-3b4c5d6e7f80",
+		"last_error":"",
+		"metadata":{
+		  "name":"Wake up"
 		},
-		"type":"light"
+		"script_id":"ff8957e3-2eb9-4699-a0c8-ad2cb3ede704",
+		"status":"running",
+		"type":"behavior_instance"
 	  },
 	  {
-		"id":"a3c5f066-f598-41eb-bae2-33d3aa34000c",
-		"id_v1":"/lights/7",
-		"metadata":{
-		  "archetype":"candle_bulb",
-		  "name":"Lamp 5"
+		"configuration":{
+		  
 		},
-		"mode":"normal",
-		"on":{
-		  "on":false
-		},
-		"owner":{
-		  "rid":"4e16129d-464c-48fc-9193-6264e463e3df",
-		  "rtype":"device"
+		"dependees":[
+		  
+		],
+		"enabled":true,
+		"id":"e5f6a7b8-c9d0-4e1f-2a3b-4c5d6e7f8091",
+		"last_error":"missing_dependee",
+		"metadata":{
+		  "name":"Night path"
 		},
-		"type":"light"
+		"script_id":"e73bc72d-96b1-46f8-aa57-729861f80c78",
+		"status":"errored",
+		"type":"behavior_instance"
 	  }
 	]
   }
 `
 
-func (tsh *testServerHandler) serveResourceLight(out http.ResponseWriter, request *http.Request) {
-	tsh.writeJSON(out, testResourceLight)
+func (tsh *testServerHandler) serveResourceBehaviorInstance(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceBehaviorInstance)
 }
 
-const testResourceTemperature = `
+const testResourceBehaviorScript = `
 {
 	"errors":[
 	  
 	],
 	"data":[
 	  {
-		"enabled":true,
-		"id":"5606a921-4315-4d66-867a-552718df8cae",
-		"id_v1":"/sensors/3",
-		"owner":{
-		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
-		  "rtype":"device"
+		"configuration_schema":{
+		  
 		},
-		"temperature":{
-		  "temperature":20.45,
-		  "temperature_valid":true
+		"description":"Get your body in the mood to wake up by fading on the lights in the morning.",
+		"id":"ff8957e3-2eb9-4699-a0c8-ad2cb3ede704",
+		"metadata":{
+		  "category":"automation",
+		  "name":"Wake up"
 		},
-		"type":"temperature"
+		"type":"behavior_script",
+		"version":"0.0.1"
+	  },
+	  {
+		"configuration_schema":{
+		  
+		},
+		"description":"Set timers",
+		"id":"e73bc72d-96b1-46f8-aa57-729861f80c78",
+		"metadata":{
+		  "category":"automation",
+		  "name":"Timers"
+		},
+		"type":"behavior_script",
+		"version":"0.0.1"
 	  }
 	]
   }
 `
 
-func (tsh *testServerHandler) serveResourceTemperature(out http.ResponseWriter, request *http.Request) {
-	tsh.writeJSON(out, testResourceTemperature)
+func (tsh *testServerHandler) serveResourceBehaviorScript(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceBehaviorScript)
 }
 
-const testResourceLightLevel = `
+const testResourceEntertainmentConfiguration = `
 {
 	"errors":[
 	  
 	],
 	"data":[
 	  {
-		"enabled":true,
-		"id":"b612b85c-1231-4aed-ac03-98250efdbb6c",
-		"id_v1":"/sensors/5",
-		"light":{
-		  "light_level":1563,
-		  "light_level_valid":true
+		"active_streamer":{
+		  "rid":"0a1b2c3d-4e5f-4a6b-7c8d-9e0f1a2b3c4d",
+		  "rtype":"auth_v1"
 		},
-		"owner":{
-		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
-		  "rtype":"device"
+		"channels":[
+		  {
+			"channel_id":0,
+			"members":[
+			  
+			],
+			"position":{
+			  "x":-0.5,
+			  "y":0.8,
+			  "z":0.0
+			}
+		  },
+		  {
+			"channel_id":1,
+			"members":[
+			  
+			],
+			"position":{
+			  "x":0.5,
+			  "y":0.8,
+			  "z":0.0
+			}
+		  }
+		],
+		"configuration_type":"screen",
+		"id":"1e2f3a4b-5c6d-4e7f-8a9b-0c1d2e3f4a5b",
+		"id_v1":"/groups/200",
+		"light_services":[
+		  {
+			"rid":"be27e7a9-acd4-42da-a1e1-ceac9e279681",
+			"rtype":"light"
+		  },
+		  {
+			"rid":"22e46126-e936-4784-a628-7e8f084e9019",
+			"rtype":"light"
+		  }
+		],
+		"metadata":{
+		  "name":"TV area"
 		},
-		"type":"light_level"
+		"name":"TV area",
+		"status":"active",
+		"type":"entertainment_configuration"
+	  },
+	  {
+		"channels":[
+		  
+		],
+		"configuration_type":"music",
+		"id":"2f3a4b5c-6d7e-4f8a-9b0c-1d2e3f4a5b6c",
+		"id_v1":"/groups/201",
+		"light_services":[
+		  {
+			"rid":"519df633-bcad-489e-a490-353b6bfaf2bf",
+			"rtype":"light"
+		  }
+		],
+		"metadata":{
+		  "name":"Music area"
+		},
+		"name":"Music area",
+		"status":"inactive",
+		"type":"entertainment_configuration"
 	  }
 	]
   }
 `
 
-func (tsh *testServerHandler) serveResourceLightLevel(out http.ResponseWriter, request *http.Request) {
-	tsh.writeJSON(out, testResourceLightLevel)
+func (tsh *testServerHandler) serveResourceEntertainmentConfiguration(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceEntertainmentConfiguration)
 }
 
-const testResourceMotion = `
+const testResourceGeolocation = `
 {
 	"errors":[
 	  
 	],
 	"data":[
 	  {
-		"enabled":true,
-		"id":"4a50cccd-b1d7-447e-bd94-3e73b2e6097a",
-		"id_v1":"/sensors/4",
-		"motion":{
-		  "motion":false,
-		  "motion_valid":true
-		},
-		"owner":{
-		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
-		  "rtype":"device"
+		"id":"5b6c7d8e-9f0a-4b1c-8d2e-3f4a5b6c7d8e",
+		"is_configured":true,
+		"sun_today":{
+		  "sunset_time":"18:57:00",
+		  "day_type":"normal_day"
 		},
-		"type":"motion"
+		"type":"geolocation"
 	  }
 	]
   }
 `
 
-func (tsh *testServerHandler) serveResourceMotion(out http.ResponseWriter, request *http.Request) {
-	tsh.writeJSON(out, testResourceMotion)
+func (tsh *testServerHandler) serveResourceGeolocation(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceGeolocation)
 }
 
-const testResourceDevicePower = `
+const testResourceGeofenceClient = `
 {
 	"errors":[
 	  
 	],
 	"data":[
 	  {
-		"id":"52d23eb6-c9b5-4641-b873-3d441888c34b",
-		"id_v1":"/sensors/4",
-		"owner":{
-		  "rid":"92cd53c4-abff-437c-bb21-1733e74c5df5",
-		  "rtype":"device"
-		},
-		"power_state":{
-		  "battery_level":100,
-		  "battery_state":"normal"
-		},
-		"type":"device_power"
+		"id":"6c7d8e9f-0a1b-4c2d-9e3f-4a5b6c7d8e9f",
+		"name":"Phone 1",
+		"type":"geofence_client"
+	  },
+	  {
+		"id":"7d8e9f0a-1b2c-4d3e-8f4a-5b6c7d8e9f0a",
+		"name":"Phone 2",
+		"type":"geofence_client"
 	  }
 	]
   }
 `
 
-func (tsh *testServerHandler) serveResourceDevicePower(out http.ResponseWriter, request *http.Request) {
-	tsh.writeJSON(out, testResourceDevicePower)
+func (tsh *testServerHandler) serveResourceGeofenceClient(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceGeofenceClient)
 }
 
 const testResourceDevice = `
@@ -367,6 +2961,16 @@ const testResourceDevice = `
 		  "archetype":"candle_bulb",
 		  "name":"Lamp 2"
 		},
+		"services":[
+		  {
+			"rid":"519df633-bcad-489e-a490-353b6bfaf2bf",
+			"rtype":"light"
+		  },
+		  {
+			"rid":"f4e6c2b9-5a1d-4e8f-9c3b-2d7a6e5f4c3b",
+			"rtype":"zigbee_connectivity"
+		  }
+		],
 		"type":"device"
 	  },
 	  {
@@ -385,6 +2989,14 @@ const testResourceDevice = `
 		  "archetype":"sultan_bulb",
 		  "name":"Lamp 4"
 		},
+		"product_data":{
+		  "certified":true,
+		  "manufacturer_name":"Signify Netherlands B.V.",
+		  "model_id":"LCA001",
+		  "product_archetype":"sultan_bulb",
+		  "product_name":"Hue color lamp",
+		  "software_version":"1.104.2"
+		},
 		"type":"device"
 	  },
 	  {
@@ -421,6 +3033,16 @@ const testResourceDevice = `
 		  "archetype":"bridge_v2",
 		  "name":"huebridge1"
 		},
+		"services":[
+		  {
+			"rid":"c2d1e0f9-a8b7-4c6d-9e5f-4a3b2c1d0e9f",
+			"rtype":"bridge"
+		  },
+		  {
+			"rid":"8e2f4a6b-1c3d-4e5f-a6b7-c8d9e0f1a2b3",
+			"rtype":"zigbee_connectivity"
+		  }
+		],
 		"type":"device"
 	  },
 	  {
@@ -458,6 +3080,24 @@ const testResourceDevice = `
 		  "name":"Motion sensor"
 		},
 		"type":"device"
+	  },
+	  {
+		"id":"c64e4b4c-4a33-4d71-8c0b-5bd84a3b6d38",
+		"id_v1":"/sensors/12",
+		"metadata":{
+		  "archetype":"unknown_archetype",
+		  "name":"Tap dial switch"
+		},
+		"type":"device"
+	  },
+	  {
+		"id":"3f0b6b9e-2d5c-4c4b-8f6a-1e2d3c4b5a69",
+		"id_v1":"/sensors/15",
+		"metadata":{
+		  "archetype":"unknown_archetype",
+		  "name":"Door sensor"
+		},
+		"type":"device"
 	  }
 	]
   }
@@ -576,6 +3216,165 @@ func (tsh *testServerHandler) serveResourceRoom(out http.ResponseWriter, request
 	tsh.writeJSON(out, testResourceRoom)
 }
 
+const testEventStream = `: hi
+
+id: 1706004000:0
+data: [{"creationtime":"2024-01-23T10:00:00Z","data":[{"id":"4a50cccd-b1d7-447e-bd94-3e73b2e6097a","id_v1":"/sensors/4","motion":{"motion":true,"motion_valid":true},"owner":{"rid":"92cd53c4-abff-437c-bb21-1733e74c5df5","rtype":"device"},"type":"motion"}],"id":"9de116fc-5fd2-4b74-8414-0f30cb2cf4f5","type":"update"}]
+
+`
+
+const testEventStreamGroupedLight = `id: 1706004000:0
+data: [{"creationtime":"2024-01-23T10:00:00Z","data":[{"id":"1b6f6e1c-3c1b-4b8a-9d0e-5f4a3b2c1d0e","id_v1":"/groups/3","on":{"on":true},"owner":{"rid":"e8006e01-92a3-4bc7-9102-a768259187b0","rtype":"room"},"type":"grouped_light"}],"id":"0c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f","type":"update"}]
+
+id: 1706004001:0
+data: [{"creationtime":"2024-01-23T10:00:01Z","data":[{"id":"ad90a702-a167-4a7c-8bf0-c87cc5938855","id_v1":"/lights/4","on":{"on":false},"owner":{"rid":"5c8131ae-c187-4408-98d3-c362c5f777a3","rtype":"device"},"type":"light"}],"id":"1d2e3f4a-5b6c-4d7e-8f9a-0b1c2d3e4f5a","type":"update"}]
+
+id: 1706004002:0
+data: [{"creationtime":"2024-01-23T10:00:02Z","data":[{"id":"1b6f6e1c-3c1b-4b8a-9d0e-5f4a3b2c1d0e","id_v1":"/groups/3","on":{"on":true},"owner":{"rid":"e8006e01-92a3-4bc7-9102-a768259187b0","rtype":"room"},"type":"grouped_light"}],"id":"2e3f4a5b-6c7d-4e8f-9a0b-1c2d3e4f5a6b","type":"update"}]
+
+`
+
+func (tsh *testServerHandler) serveEventStream(out http.ResponseWriter, request *http.Request) {
+	out.Header().Add("Content-Type", "text/event-stream")
+	eventStream := tsh.EventStream
+	if eventStream == "" {
+		eventStream = testEventStream
+	}
+	_, _ = out.Write([]byte(eventStream))
+	out.(http.Flusher).Flush()
+	// the first stream is closed right away to force a reconnect (if requested)
+	if tsh.eventStreamConnects.Add(1) == 1 && tsh.CloseEventStream {
+		return
+	}
+	<-request.Context().Done()
+}
+
+const testResourceZone = `
+{
+	"errors":[
+	  
+	],
+	"data":[
+	  {
+		"children":[
+		  {
+			"rid":"ad90a702-a167-4a7c-8bf0-c87cc5938855",
+			"rtype":"light"
+		  },
+		  {
+			"rid":"519df633-bcad-489e-a490-353b6bfaf2bf",
+			"rtype":"light"
+		  }
+		],
+		"id":"9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d",
+		"id_v1":"/groups/6",
+		"metadata":{
+		  "archetype":"downstairs",
+		  "name":"Downstairs"
+		},
+		"services":[
+		  {
+			"rid":"2c7a7f2d-4d2c-4c9b-8e1f-6a5b4c3d2e1f",
+			"rtype":"grouped_light"
+		  }
+		],
+		"type":"zone"
+	  }
+	]
+  }
+`
+
+func (tsh *testServerHandler) serveResourceZone(out http.ResponseWriter, request *http.Request) {
+	tsh.writeJSON(out, testResourceZone)
+}
+
+var testResourceSnapshotParts = []string{
+	testResourceBridge,
+	testResourceBridgeZigbeeConnectivity,
+	testResourceLight,
+	testResourceTemperature,
+	testResourceLightLevel,
+	testResourceMotion,
+	testResourceCameraMotion,
+	testResourceConvenienceAreaMotion,
+	testResourceMotionAreaConfiguration,
+	testResourceGroupedMotion,
+	testResourceGroupedLightLevel,
+	testResourceDevicePower,
+	testResourceButton,
+	testResourceRelativeRotary,
+	testResourceContact,
+	testResourceTamper,
+	testResourceZigbeeConnectivity,
+	testResourceZgpConnectivity,
+	testResourceGroupedLight,
+	testResourceDeviceSoftwareUpdate,
+	testResourceScene,
+	testResourceSmartScene,
+	testResourceBehaviorInstance,
+	testResourceBehaviorScript,
+	testResourceEntertainmentConfiguration,
+	testResourceGeolocation,
+	testResourceGeofenceClient,
+	testResourceDevice,
+	testResourceRoom,
+	testResourceZone,
+}
+
+func (tsh *testServerHandler) serveResourceSnapshot(out http.ResponseWriter, request *http.Request) {
+	var snapshot struct {
+		Errors []json.RawMessage `json:"errors"`
+		Data   []json.RawMessage `json:"data"`
+	}
+	snapshot.Errors = []json.RawMessage{}
+	for _, part := range testResourceSnapshotParts {
+		var partList struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		err := json.Unmarshal([]byte(part), &partList)
+		if err != nil {
+			out.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		snapshot.Data = append(snapshot.Data, partList.Data...)
+	}
+	snapshotJSON, err := json.Marshal(&snapshot)
+	if err != nil {
+		out.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	tsh.writeJSON(out, string(snapshotJSON))
+}
+
+// serveResourceItem serves a single resource of the given resource list (like a request for /clip/v2/resource/<type>/<id>)
+func (tsh *testServerHandler) serveResourceItem(out http.ResponseWriter, request *http.Request, resources string, id string) {
+	var resourceList struct {
+		Errors []json.RawMessage        `json:"errors"`
+		Data   []map[string]interface{} `json:"data"`
+	}
+	err := json.Unmarshal([]byte(resources), &resourceList)
+	if err != nil {
+		out.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var item struct {
+		Errors []json.RawMessage        `json:"errors"`
+		Data   []map[string]interface{} `json:"data"`
+	}
+	item.Errors = []json.RawMessage{}
+	for _, resource := range resourceList.Data {
+		if resource["id"] == id {
+			item.Data = append(item.Data, resource)
+		}
+	}
+	itemJSON, err := json.Marshal(&item)
+	if err != nil {
+		out.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	tsh.writeJSON(out, string(itemJSON))
+}
+
 func (tsh *testServerHandler) writeJSON(out http.ResponseWriter, json string) {
 	out.Header().Add("Content-Type", "application/json")
 	_, _ = out.Write([]byte(json))
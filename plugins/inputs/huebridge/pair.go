@@ -0,0 +1,106 @@
+// pair.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package huebridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const pairPath = "/api"
+const pairErrorLinkButtonNotPressed = 101
+
+var pairPollInterval = 2 * time.Second
+
+type pairRequest struct {
+	DeviceType        string `json:"devicetype"`
+	GenerateClientKey bool   `json:"generateclientkey"`
+}
+
+type pairResponse struct {
+	Success *pairSuccess `json:"success"`
+	Error   *pairError   `json:"error"`
+}
+
+type pairSuccess struct {
+	Username  string `json:"username"`
+	ClientKey string `json:"clientkey"`
+}
+
+type pairError struct {
+	Type        int    `json:"type"`
+	Description string `json:"description"`
+}
+
+// Pair requests a new application key from the given bridge. As the bridge only grants a key after
+// it's link button has been pressed, the request is repeated until the button has been pressed or
+// the timeout has elapsed.
+func Pair(bridgeUrl string, deviceType string, timeout time.Duration) (string, error) {
+	plugin := NewHueBridge()
+	pairUrl, err := resolveUrl(bridgeUrl, pairPath)
+	if err != nil {
+		return "", err
+	}
+	requestBody, err := json.Marshal(&pairRequest{DeviceType: deviceType, GenerateClientKey: true})
+	if err != nil {
+		return "", err
+	}
+	client, err := plugin.getClient(plugin.bridgeConfig(bridgeUrl))
+	if err != nil {
+		return "", err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		response, err := client.Post(pairUrl.String(), "application/json", bytes.NewReader(requestBody))
+		if err != nil {
+			return "", err
+		}
+		var pairResponses []pairResponse
+		if response.StatusCode == http.StatusOK {
+			err = json.NewDecoder(response.Body).Decode(&pairResponses)
+		} else {
+			err = fmt.Errorf("failed to pair with %s (%s)", pairUrl, response.Status)
+		}
+		response.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		if len(pairResponses) != 1 {
+			return "", fmt.Errorf("unexpected pairing response received from %s", pairUrl)
+		}
+		if pairResponses[0].Success != nil {
+			return pairResponses[0].Success.Username, nil
+		}
+		if pairResponses[0].Error == nil || pairResponses[0].Error.Type != pairErrorLinkButtonNotPressed {
+			return "", fmt.Errorf("failed to pair with %s (%s)", pairUrl, pairResponses[0].Error.describe())
+		}
+		if time.Now().Add(pairPollInterval).After(deadline) {
+			return "", fmt.Errorf("failed to pair with %s (link button not pressed within %s)", pairUrl, timeout)
+		}
+		time.Sleep(pairPollInterval)
+	}
+}
+
+func (e *pairError) describe() string {
+	if e == nil {
+		return "unexpected response"
+	}
+	return fmt.Sprintf("error %d: %s", e.Type, e.Description)
+}
+
+// PairedConfig creates a ready-to-use plugin configuration for the given bridge and application key.
+func PairedConfig(bridgeUrl string, applicationKey string) string {
+	bridges := fmt.Sprintf("bridges = [[%q, %q]]", bridgeUrl, applicationKey)
+	sampleConfig := NewHueBridge().SampleConfig()
+	sampleConfig = strings.Replace(sampleConfig, `bridges = [["https://<insert IP or DNS name>", "<insert application key>"]]`, bridges, 1)
+	return "[[inputs.huebridge]]" + sampleConfig
+}
@@ -0,0 +1,95 @@
+// snapshot.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package huebridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const snapshotPath = "/clip/v2/resource"
+
+// resourceSnapshot holds all resources of a bridge as returned by a single request. While a snapshot
+// is active for a bridge, all resource requests are served from the snapshot.
+type resourceSnapshot struct {
+	resourcesByType map[string][]json.RawMessage
+	resourcesById   map[string]json.RawMessage
+}
+
+type snapshotResource struct {
+	Id   string `json:"id"`
+	Type string `json:"type"`
+}
+
+func (plugin *HueBridge) takeSnapshot(bridgeUrl string, applicationKey string) error {
+	var snapshotList struct {
+		Data []json.RawMessage `json:"data"`
+	}
+
+	_, err := plugin.fetchJSON(bridgeUrl, applicationKey, snapshotPath, &snapshotList)
+	if err != nil {
+		return err
+	}
+	snapshot := &resourceSnapshot{
+		resourcesByType: make(map[string][]json.RawMessage),
+		resourcesById:   make(map[string]json.RawMessage),
+	}
+	for _, resourceData := range snapshotList.Data {
+		var resource snapshotResource
+		err := json.Unmarshal(resourceData, &resource)
+		if err != nil {
+			return err
+		}
+		snapshot.resourcesByType[resource.Type] = append(snapshot.resourcesByType[resource.Type], resourceData)
+		snapshot.resourcesById[resource.Type+"/"+resource.Id] = resourceData
+	}
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	if plugin.snapshots == nil {
+		plugin.snapshots = make(map[string]*resourceSnapshot)
+	}
+	plugin.snapshots[bridgeUrl] = snapshot
+	return nil
+}
+
+func (plugin *HueBridge) releaseSnapshot(bridgeUrl string) {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	delete(plugin.snapshots, bridgeUrl)
+}
+
+func (plugin *HueBridge) getSnapshot(bridgeUrl string) *resourceSnapshot {
+	plugin.stateLock.Lock()
+	defer plugin.stateLock.Unlock()
+	return plugin.snapshots[bridgeUrl]
+}
+
+// decode decodes the resources addressed by the given path the same way the bridge would have
+// returned them. Paths outside the resource API are not handled by the snapshot.
+func (snapshot *resourceSnapshot) decode(path string, v interface{}) (bool, error) {
+	resourcePath, found := strings.CutPrefix(path, snapshotPath+"/")
+	if !found {
+		return false, nil
+	}
+	var resources []json.RawMessage
+	if strings.Contains(resourcePath, "/") {
+		resourceData := snapshot.resourcesById[resourcePath]
+		if resourceData == nil {
+			return true, fmt.Errorf("failed to retrieve %s from snapshot (%w)", resourcePath, errResourceNotFound)
+		}
+		resources = append(resources, resourceData)
+	} else {
+		resources = snapshot.resourcesByType[resourcePath]
+	}
+	data, err := json.Marshal(map[string][]json.RawMessage{"data": resources})
+	if err != nil {
+		return true, err
+	}
+	return true, json.Unmarshal(data, v)
+}
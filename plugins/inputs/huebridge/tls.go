@@ -0,0 +1,174 @@
+// tls.go
+//
+// Copyright (C) 2022-2024 Holger de Carne
+//
+// This software may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+
+package huebridge
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const tlsVerifyNone = "none"
+const tlsVerifyHue = "hue"
+const tlsVerifyCA = "ca"
+const tlsVerifyFingerprint = "fingerprint"
+
+// The root CA (CN=root-bridge, O=Philips Hue) issuing the bridge certificates
+//
+//go:embed hue_root_ca.pem
+var hueRootCA []byte
+
+// createTransport creates the http transport used to access the bridges with the configured
+// TLS verification applied.
+func (plugin *HueBridge) createTransport(bridge *BridgeConfig) (*http.Transport, error) {
+	tlsConfig, err := plugin.createTLSConfig(bridge)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		ResponseHeaderTimeout: time.Duration(bridge.Timeout) * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}
+	if bridge.TLSVerify == tlsVerifyHue {
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(hueRootCA) {
+			return nil, errors.New("failed to load Hue root CA")
+		}
+		// the expected bridge id depends on the address connected to, which is not available
+		// within the connection state in case of IP addresses (no SNI); hence the handshake is
+		// performed by ourselves
+		dialer := &net.Dialer{Timeout: time.Duration(bridge.Timeout) * time.Second}
+		transport.DialTLSContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			connTLSConfig := tlsConfig.Clone()
+			connTLSConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+				return plugin.verifyHueCertificate(bridge, cs, roots, host)
+			}
+			tlsConn := tls.Client(conn, connTLSConfig)
+			err = tlsConn.HandshakeContext(ctx)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		}
+	}
+	return transport, nil
+}
+
+func (plugin *HueBridge) createTLSConfig(bridge *BridgeConfig) (*tls.Config, error) {
+	tlsConfig, err := bridge.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	switch bridge.TLSVerify {
+	case "", tlsVerifyNone:
+		tlsConfig.InsecureSkipVerify = true
+	case tlsVerifyHue:
+		// the bridge certificates are issued for the bridge id (instead of the bridge address),
+		// hence the standard verification is replaced by our own one (see createTransport)
+		tlsConfig.InsecureSkipVerify = true
+	case tlsVerifyCA:
+		// standard verification against the system or the configured (tls_ca) CAs
+	case tlsVerifyFingerprint:
+		fingerprints := make(map[string]bool)
+		for _, fingerprint := range bridge.TLSFingerprints {
+			fingerprints[normalizeFingerprint(fingerprint)] = true
+		}
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyFingerprint(cs, fingerprints)
+		}
+	default:
+		return nil, fmt.Errorf("huebridge: Invalid tls_verify option: %s", bridge.TLSVerify)
+	}
+	return tlsConfig, nil
+}
+
+func (plugin *HueBridge) verifyHueCertificate(bridge *BridgeConfig, cs tls.ConnectionState, roots *x509.CertPool, host string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no bridge certificate received")
+	}
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(intermediate)
+	}
+	certificate := cs.PeerCertificates[0]
+	_, err := certificate.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return err
+	}
+	bridgeId := plugin.expectedBridgeId(bridge, host)
+	if bridgeId == "" {
+		return fmt.Errorf("unknown bridge id for %s (use auto:<bridge id> or tls_server_name to define it)", host)
+	}
+	if !strings.EqualFold(certificate.Subject.CommonName, bridgeId) {
+		return fmt.Errorf("bridge certificate issued for %s (expected: %s)", certificate.Subject.CommonName, bridgeId)
+	}
+	return nil
+}
+
+// expectedBridgeId determines the bridge id expected for a connection either via the tls_server_name
+// option, the auto:<bridge id> url or the discovered bridge the connected address belongs to.
+func (plugin *HueBridge) expectedBridgeId(bridge *BridgeConfig, host string) string {
+	if bridge.ServerName != "" {
+		return bridge.ServerName
+	}
+	bridgeId, found := strings.CutPrefix(bridge.Url, autoBridgePrefix)
+	if found {
+		return bridgeId
+	}
+	plugin.discoveryLock.Lock()
+	defer plugin.discoveryLock.Unlock()
+	for bridgeId, bridgeUrl := range plugin.discoveredBridges {
+		parsedBridgeUrl, err := url.Parse(bridgeUrl)
+		if err == nil && parsedBridgeUrl.Hostname() == host {
+			return bridgeId
+		}
+	}
+	return ""
+}
+
+func verifyFingerprint(cs tls.ConnectionState, fingerprints map[string]bool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("no bridge certificate received")
+	}
+	fingerprint := sha256.Sum256(cs.PeerCertificates[0].Raw)
+	if !fingerprints[hex.EncodeToString(fingerprint[:])] {
+		return fmt.Errorf("bridge certificate fingerprint %s not pinned", hex.EncodeToString(fingerprint[:]))
+	}
+	return nil
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}